@@ -0,0 +1,234 @@
+package maelstrom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errMalformedBody is returned when an encoded body cannot be scanned.
+var errMalformedBody = errors.New("malformed message body")
+
+// encodeBody marshals body and sets the reserved integer field key to value.
+// The encoded body is not otherwise modified so numbers & field order are
+// preserved. A nil body is encoded as an empty object.
+func encodeBody(body any, key string, value int) ([]byte, error) {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return setBodyField(buf, key, value)
+}
+
+// setBodyField sets the top-level field key of the JSON object buf to an
+// integer value.
+func setBodyField(buf []byte, key string, value int) ([]byte, error) {
+	return setBodyRaw(buf, key, strconv.AppendInt(nil, int64(value), 10))
+}
+
+// setBodyRaw sets the top-level field key of the JSON object buf to the
+// encoded value. An existing field is replaced. Otherwise, the field is
+// inserted before the first key which sorts after it so that sorted objects
+// remain sorted. The value must be compact JSON, as produced by json.Marshal.
+func setBodyRaw(buf []byte, key string, value []byte) ([]byte, error) {
+	if bytes.Equal(buf, []byte("null")) {
+		buf = []byte("{}")
+	}
+
+	field := strconv.AppendQuote(nil, key)
+	field = append(field, ':')
+	field = append(field, value...)
+
+	replace, insert := -1, -1
+	var replaceEnd, fields int
+	end, err := scanFields(buf, func(k string, start, _, next int) bool {
+		fields++
+		if k == key {
+			replace, replaceEnd = start, next
+			return false
+		} else if k > key && insert == -1 {
+			insert = start
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	} else if replace != -1 {
+		return splice(buf, replace, replaceEnd, field), nil
+	} else if insert != -1 {
+		return splice(buf, insert, insert, append(field, ',')), nil
+	}
+
+	// Append the field to the end of the object.
+	if fields > 0 {
+		field = append([]byte{','}, field...)
+	}
+	return splice(buf, end, end, field), nil
+}
+
+// bodyField returns the encoded value of the top-level field key of the JSON
+// object buf, or nil if the field is not set.
+func bodyField(buf []byte, key string) ([]byte, error) {
+	if bytes.Equal(buf, []byte("null")) {
+		return nil, nil
+	}
+
+	var value []byte
+	if _, err := scanFields(buf, func(k string, _, valueStart, next int) bool {
+		if k == key {
+			value = buf[valueStart:next]
+			return false
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// bodyType returns the "type" field of the JSON object buf, or a blank string
+// if it is not set or is not a string.
+func bodyType(buf []byte) string {
+	v, err := bodyField(buf, "type")
+	if err != nil || len(v) == 0 || v[0] != '"' {
+		return ""
+	}
+	var typ string
+	if err := json.Unmarshal(v, &typ); err != nil {
+		return ""
+	}
+	return typ
+}
+
+// scanFields calls fn for each top-level field of the JSON object buf with
+// its key, the index of the key, the index of the value & the index after the
+// value. Scanning stops when fn returns false. Returns the index of the
+// closing brace, or of the stopping field.
+func scanFields(buf []byte, fn func(key string, start, value, next int) bool) (int, error) {
+	if len(buf) < 2 || buf[0] != '{' {
+		return 0, fmt.Errorf("message body must be a JSON object")
+	}
+
+	i := skipSpace(buf, 1)
+	for i < len(buf) && buf[i] != '}' {
+		// Read the key & find the end of its value.
+		start := i
+		end, err := scanString(buf, i)
+		if err != nil {
+			return 0, err
+		}
+		k, err := unquoteKey(buf[start:end])
+		if err != nil {
+			return 0, err
+		}
+		if end = skipSpace(buf, end); end >= len(buf) || buf[end] != ':' {
+			return 0, errMalformedBody
+		}
+		value := skipSpace(buf, end+1)
+		next, err := scanValue(buf, value)
+		if err != nil {
+			return 0, err
+		}
+
+		if !fn(k, start, value, next) {
+			return start, nil
+		}
+
+		i = skipSpace(buf, next)
+		if i < len(buf) && buf[i] == ',' {
+			i = skipSpace(buf, i+1)
+		}
+	}
+	if i >= len(buf) {
+		return 0, errMalformedBody
+	}
+	return i, nil
+}
+
+// skipSpace returns the index of the first non-whitespace byte at or after i.
+func skipSpace(buf []byte, i int) int {
+	for i < len(buf) && (buf[i] == ' ' || buf[i] == '\t' || buf[i] == '\n' || buf[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// splice returns a copy of buf with buf[start:end] replaced by b.
+func splice(buf []byte, start, end int, b []byte) []byte {
+	other := make([]byte, 0, len(buf)-(end-start)+len(b))
+	other = append(other, buf[:start]...)
+	other = append(other, b...)
+	return append(other, buf[end:]...)
+}
+
+// unquoteKey returns the string value of the quoted object key b.
+func unquoteKey(b []byte) (string, error) {
+	if bytes.IndexByte(b, '\\') == -1 {
+		return string(b[1 : len(b)-1]), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return "", errMalformedBody
+	}
+	return s, nil
+}
+
+// scanString returns the index after the JSON string which begins at i.
+func scanString(buf []byte, i int) (int, error) {
+	if i >= len(buf) || buf[i] != '"' {
+		return 0, errMalformedBody
+	}
+	for i++; i < len(buf); i++ {
+		switch buf[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, errMalformedBody
+}
+
+// scanValue returns the index after the JSON value which begins at i.
+func scanValue(buf []byte, i int) (int, error) {
+	if i >= len(buf) {
+		return 0, errMalformedBody
+	}
+
+	switch buf[i] {
+	case '"':
+		return scanString(buf, i)
+
+	case '{', '[':
+		depth := 0
+		for i < len(buf) {
+			switch buf[i] {
+			case '"':
+				end, err := scanString(buf, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1, nil
+				}
+			}
+			i++
+		}
+		return 0, errMalformedBody
+
+	default:
+		// Numbers, booleans & null end at the next delimiter or whitespace.
+		for i < len(buf) && buf[i] != ',' && buf[i] != '}' && buf[i] != ']' && skipSpace(buf, i) == i {
+			i++
+		}
+		return i, nil
+	}
+}
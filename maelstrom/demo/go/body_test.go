@@ -0,0 +1,64 @@
+package maelstrom_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Ensure reply IDs are injected into the encoded body without altering it.
+func TestNode_Reply_Body(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		body any
+		want string
+	}{
+		{"Empty", nil, `{"in_reply_to":1}`},
+		{"Sorted", map[string]any{"type": "foo_ok", "a": 1}, `{"a":1,"in_reply_to":1,"type":"foo_ok"}`},
+		{"Append", map[string]any{"a": 1}, `{"a":1,"in_reply_to":1}`},
+		{"Replace", map[string]any{"in_reply_to": 99, "type": "foo_ok"}, `{"in_reply_to":1,"type":"foo_ok"}`},
+		{"Struct", maelstrom.MessageBody{Type: "foo_ok", MsgID: 2}, `{"in_reply_to":1,"type":"foo_ok","msg_id":2}`},
+		{"LargeNumber", json.RawMessage(`{"type":"foo_ok","value":12345678901234567890}`), `{"in_reply_to":1,"type":"foo_ok","value":12345678901234567890}`},
+		{"Nested", json.RawMessage(`{"a":{"in_reply_to":5,"s":"}\","},"b":[{"c":"]"}],"z":true}`), `{"a":{"in_reply_to":5,"s":"}\","},"b":[{"c":"]"}],"in_reply_to":1,"z":true}`},
+		{"EscapedKey", json.RawMessage(`{"in\u005freply_to":3}`), `{"in_reply_to":1}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			n := maelstrom.NewNode()
+			n.Stdout = &stdout
+
+			req := maelstrom.Message{Src: "c1", Body: json.RawMessage(`{"type":"foo","msg_id":1}`)}
+			if err := n.Reply(req, tt.body); err != nil {
+				t.Fatal(err)
+			} else if got, want := stdout.String(), `{"dest":"c1","body":`+tt.want+"}\n"; got != want {
+				t.Fatalf("stdout=%s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("ErrNotObject", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.Stdout = &bytes.Buffer{}
+
+		req := maelstrom.Message{Src: "c1", Body: json.RawMessage(`{"type":"foo","msg_id":1}`)}
+		if err := n.Reply(req, []int{1}); err == nil || err.Error() != `message body must be a JSON object` {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+// Ensure message IDs are injected into the encoded body of RPC requests.
+func TestNode_RPC_Body(t *testing.T) {
+	var stdout bytes.Buffer
+	n := maelstrom.NewNode()
+	n.Stdout = &stdout
+
+	body := json.RawMessage(`{"type":"foo","value":9007199254740993,"msg_id":0}`)
+	if err := n.RPC("n2", body, func(msg maelstrom.Message) error { return nil }); err != nil {
+		t.Fatal(err)
+	} else if got, want := stdout.String(), `{"dest":"n2","body":{"type":"foo","value":9007199254740993,"msg_id":1}}`+"\n"; got != want {
+		t.Fatalf("stdout=%s, want %s", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package checker
+
+import (
+	"sort"
+)
+
+// CheckBroadcast checks a broadcast history. Broadcast operations have an F
+// of "broadcast" and an int value. Read operations have an F of "read" and
+// complete with an []int value.
+//
+// Only final reads are checked: the last successful read of each process
+// which was invoked after every acknowledged broadcast completed. Every
+// acknowledged broadcast must be present in every final read and reads must
+// only contain values which were broadcast.
+func CheckBroadcast(h History) Result {
+	res := Result{Valid: true}
+
+	pairs := h.Pairs()
+	lastAck := lastAcked(pairs, "broadcast")
+
+	attempted := make(map[int]bool)
+	var acked []int
+	final := make(map[int]Op) // process to final read
+	for _, p := range pairs {
+		switch p.Invoke.F {
+		case "broadcast":
+			v, ok := p.Invoke.Value.(int)
+			if !ok {
+				res.add("malformed", valueError(p.Invoke, "int")).Ops = []Op{p.Invoke}
+				continue
+			}
+			attempted[v] = true
+			if p.Completion.Type == OK {
+				acked = append(acked, v)
+			}
+
+		case "read":
+			if p.Completion.Type == OK && p.Invoke.Index > lastAck {
+				final[p.Completion.Process] = p.Completion
+			}
+		}
+	}
+
+	// Iterate over processes in order so results are deterministic.
+	processes := make([]int, 0, len(final))
+	for process := range final {
+		processes = append(processes, process)
+	}
+	sort.Ints(processes)
+
+	for _, process := range processes {
+		read := final[process]
+		values, ok := read.Value.([]int)
+		if !ok {
+			res.add("malformed", valueError(read, "[]int")).Ops = []Op{read}
+			continue
+		}
+
+		seen := make(map[int]bool, len(values))
+		for _, v := range values {
+			seen[v] = true
+			if !attempted[v] {
+				res.add("unexpected", "read observed %d which was never broadcast", v).Ops = []Op{read}
+			}
+		}
+
+		var lost []int
+		for _, v := range acked {
+			if !seen[v] {
+				lost = append(lost, v)
+			}
+		}
+		if len(lost) > 0 {
+			res.add("lost", "final read is missing acknowledged broadcasts %v", lost).Ops = []Op{read}
+		}
+	}
+	return res
+}
@@ -0,0 +1,65 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+)
+
+func TestCheckBroadcast(t *testing.T) {
+	res := checker.CheckBroadcast(history(
+		invoke(1, "broadcast", 1),
+		ok(1, "broadcast", nil),
+		invoke(2, "broadcast", 2),
+		info(2, "broadcast", 2),
+		invoke(1, "read", nil),
+		ok(1, "read", []int{}),
+		invoke(1, "read", nil),
+		ok(1, "read", []int{1, 2}),
+		invoke(3, "read", nil),
+		ok(3, "read", []int{1}),
+	))
+	expectAnomalies(t, res)
+}
+
+// Ensure final reads missing acknowledged broadcasts are reported.
+func TestCheckBroadcast_Lost(t *testing.T) {
+	res := checker.CheckBroadcast(history(
+		invoke(1, "broadcast", 1),
+		ok(1, "broadcast", nil),
+		invoke(1, "broadcast", 2),
+		ok(1, "broadcast", nil),
+		invoke(2, "read", nil),
+		ok(2, "read", []int{2}),
+		invoke(3, "read", nil),
+		ok(3, "read", []int{1, 2}),
+	))
+	expectAnomalies(t, res, "lost")
+	if got, want := res.Anomalies[0].Ops[0].Process, 2; got != want {
+		t.Fatalf("process=%d, want %d", got, want)
+	}
+}
+
+// Ensure reads of values which were never broadcast are reported.
+func TestCheckBroadcast_Unexpected(t *testing.T) {
+	res := checker.CheckBroadcast(history(
+		invoke(1, "broadcast", 1),
+		ok(1, "broadcast", nil),
+		invoke(1, "read", nil),
+		ok(1, "read", []int{1, 3}),
+	))
+	expectAnomalies(t, res, "unexpected")
+}
+
+// Ensure reads invoked before the last acknowledged broadcast are not final.
+func TestCheckBroadcast_EarlyRead(t *testing.T) {
+	res := checker.CheckBroadcast(history(
+		invoke(0, "read", nil),
+		ok(0, "read", []int{}),
+		invoke(1, "broadcast", 1),
+		ok(1, "broadcast", nil),
+		invoke(1, "read", nil),
+		ok(1, "read", []int{1}),
+	))
+	expectAnomalies(t, res)
+}
@@ -0,0 +1,84 @@
+package checker
+
+import (
+	"sort"
+)
+
+// CheckCounter checks a g-counter or pn-counter history. Add operations have
+// an F of "add" and an int delta value. Read operations have an F of "read"
+// and complete with an int value.
+//
+// Counters are only eventually consistent, so only final reads are checked:
+// the last successful read of each process which was invoked after every
+// acknowledged add completed. Final reads must lie between the sum of all
+// acknowledged deltas & the sum of all deltas which may have been applied,
+// including indeterminate ones.
+func CheckCounter(h History) Result {
+	res := Result{Valid: true}
+
+	pairs := h.Pairs()
+	lastAck := lastAcked(pairs, "add")
+
+	var lower, upper int
+	final := make(map[int]Op) // process to final read
+	for _, p := range pairs {
+		switch p.Invoke.F {
+		case "add":
+			delta, ok := p.Invoke.Value.(int)
+			if !ok {
+				res.add("malformed", valueError(p.Invoke, "int")).Ops = []Op{p.Invoke}
+				continue
+			}
+
+			switch p.Completion.Type {
+			case OK:
+				lower += delta
+				upper += delta
+			case Info:
+				// Indeterminate deltas widen the bounds in their direction.
+				if delta > 0 {
+					upper += delta
+				} else {
+					lower += delta
+				}
+			}
+
+		case "read":
+			if p.Completion.Type == OK && p.Invoke.Index > lastAck {
+				final[p.Completion.Process] = p.Completion
+			}
+		}
+	}
+
+	processes := make([]int, 0, len(final))
+	for process := range final {
+		processes = append(processes, process)
+	}
+	sort.Ints(processes)
+
+	for _, process := range processes {
+		read := final[process]
+		v, ok := read.Value.(int)
+		if !ok {
+			res.add("malformed", valueError(read, "int")).Ops = []Op{read}
+			continue
+		}
+
+		if v < lower || v > upper {
+			res.add("out-of-bounds", "final read %d is not within [%d, %d]", v, lower, upper).Ops = []Op{read}
+		}
+	}
+	return res
+}
+
+// lastAcked returns the index of the last successful completion of f, or -1
+// if no operation of f succeeded.
+func lastAcked(pairs []Pair, f string) int {
+	last := -1
+	for _, p := range pairs {
+		if p.Invoke.F == f && p.Completion.Type == OK && p.Completion.Index > last {
+			last = p.Completion.Index
+		}
+	}
+	return last
+}
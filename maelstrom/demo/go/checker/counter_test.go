@@ -0,0 +1,60 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+)
+
+func TestCheckCounter(t *testing.T) {
+	res := checker.CheckCounter(history(
+		invoke(1, "add", 2),
+		ok(1, "add", 2),
+		invoke(2, "add", 3),
+		info(2, "add", 3),
+		invoke(3, "add", 4),
+		fail(3, "add", 4),
+		invoke(1, "read", nil),
+		ok(1, "read", 2),
+		invoke(3, "read", nil),
+		ok(3, "read", 5),
+	))
+	expectAnomalies(t, res)
+}
+
+// Ensure final reads outside the possible bounds are reported.
+func TestCheckCounter_OutOfBounds(t *testing.T) {
+	for _, v := range []int{1, 6} {
+		res := checker.CheckCounter(history(
+			invoke(1, "add", 2),
+			ok(1, "add", 2),
+			invoke(2, "add", 3),
+			info(2, "add", 3),
+			invoke(1, "read", nil),
+			ok(1, "read", v),
+		))
+		expectAnomalies(t, res, "out-of-bounds")
+	}
+}
+
+// Ensure reads invoked before the last acknowledged add are not final.
+func TestCheckCounter_EarlyRead(t *testing.T) {
+	res := checker.CheckCounter(history(
+		invoke(0, "read", nil),
+		ok(0, "read", 0),
+		invoke(1, "add", 5),
+		ok(1, "add", 5),
+		invoke(1, "read", nil),
+		ok(1, "read", 5),
+	))
+	expectAnomalies(t, res)
+
+	// A read concurrent with the add may still be stale.
+	res = checker.CheckCounter(history(
+		invoke(1, "add", 5),
+		invoke(0, "read", nil),
+		ok(0, "read", 0),
+		ok(1, "add", 5),
+	))
+	expectAnomalies(t, res)
+}
@@ -0,0 +1,204 @@
+// Package checker verifies the safety of operation histories recorded by
+// in-process tests. It provides Go versions of the checks Maelstrom performs
+// for its workloads: broadcast completeness, counter bounds, kafka offsets,
+// linearizability of a key/value store & transactional anomalies.
+package checker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// OpType is the type of an operation event in a history.
+type OpType string
+
+// Operation event types.
+const (
+	// Invoke marks the start of an operation.
+	Invoke OpType = "invoke"
+
+	// OK marks an operation which definitely completed.
+	OK OpType = "ok"
+
+	// Fail marks an operation which definitely did not take place.
+	Fail OpType = "fail"
+
+	// Info marks an operation which may or may not have taken place.
+	Info OpType = "info"
+)
+
+// Op represents a single event in a history. Every operation consists of an
+// Invoke event followed by an OK, Fail or Info completion by the same process.
+type Op struct {
+	Index   int
+	Process int
+	Type    OpType
+	F       string
+	Value   any
+	Time    time.Duration // since the start of the history
+	Error   error         // set for failed & indeterminate completions
+}
+
+// String returns a string representation of the op.
+func (op Op) String() string {
+	s := fmt.Sprintf("{index=%d process=%d type=%s f=%s value=%v}", op.Index, op.Process, op.Type, op.F, op.Value)
+	if op.Error != nil {
+		s = s[:len(s)-1] + fmt.Sprintf(" error=%q}", op.Error)
+	}
+	return s
+}
+
+// History represents an ordered list of operation events.
+type History []Op
+
+// Pair represents an invocation paired with its completion.
+type Pair struct {
+	Invoke     Op
+	Completion Op // Type is Info if the history ended before a completion.
+}
+
+// Pairs matches every invocation in the history with its completion. Invoked
+// operations without a completion are treated as indeterminate.
+func (h History) Pairs() []Pair {
+	var pairs []Pair
+	open := make(map[int]int) // process to index in pairs
+	for _, op := range h {
+		if op.Type == Invoke {
+			open[op.Process] = len(pairs)
+			pairs = append(pairs, Pair{Invoke: op, Completion: Op{Index: -1, Process: op.Process, Type: Info, F: op.F, Value: op.Value}})
+			continue
+		}
+
+		if i, ok := open[op.Process]; ok {
+			pairs[i].Completion = op
+			delete(open, op.Process)
+		}
+	}
+	return pairs
+}
+
+// Filter returns the events in the history for which fn returns true.
+func (h History) Filter(fn func(Op) bool) History {
+	var other History
+	for _, op := range h {
+		if fn(op) {
+			other = append(other, op)
+		}
+	}
+	return other
+}
+
+// Recorder records a history from concurrently executing operations. It is
+// safe to use from multiple goroutines.
+type Recorder struct {
+	mu    sync.Mutex
+	start time.Time
+	ops   History
+}
+
+// NewRecorder returns a new, empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{start: time.Now()}
+}
+
+// History returns a copy of the recorded history.
+func (r *Recorder) History() History {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(History(nil), r.ops...)
+}
+
+// Record appends an event to the history & returns it with its index & time set.
+func (r *Recorder) Record(op Op) Op {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	op.Index = len(r.ops)
+	op.Time = time.Since(r.start)
+	r.ops = append(r.ops, op)
+	return op
+}
+
+// Do records the invocation of f with value by process, executes fn and
+// records its completion. The completion value is the value returned by fn.
+//
+// Errors with a definite RPC error code are recorded as failures. All other
+// errors, such as timeouts & crashes, are recorded as indeterminate.
+func (r *Recorder) Do(process int, f string, value any, fn func() (any, error)) error {
+	r.Record(Op{Process: process, Type: Invoke, F: f, Value: value})
+
+	result, err := fn()
+	switch {
+	case err == nil:
+		r.Record(Op{Process: process, Type: OK, F: f, Value: result})
+	case definite(err):
+		r.Record(Op{Process: process, Type: Fail, F: f, Value: value, Error: err})
+	default:
+		r.Record(Op{Process: process, Type: Info, F: f, Value: value, Error: err})
+	}
+	return err
+}
+
+// definite returns true if err guarantees that the operation did not occur.
+func definite(err error) bool {
+	switch maelstrom.ErrorCode(err) {
+	case maelstrom.NotSupported, maelstrom.TemporarilyUnavailable, maelstrom.MalformedRequest,
+		maelstrom.Abort, maelstrom.KeyDoesNotExist, maelstrom.KeyAlreadyExists,
+		maelstrom.PreconditionFailed, maelstrom.TxnConflict:
+		return true
+	default:
+		return false
+	}
+}
+
+// Result represents the outcome of checking a history.
+type Result struct {
+	Valid     bool
+	Anomalies []Anomaly
+}
+
+// String returns a summary of the result & its anomalies.
+func (r Result) String() string {
+	if r.Valid {
+		return "valid"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid: %d anomalies", len(r.Anomalies))
+	for _, a := range r.Anomalies {
+		fmt.Fprintf(&b, "\n- %s", a)
+	}
+	return b.String()
+}
+
+// add appends an anomaly to the result and marks it invalid.
+func (r *Result) add(typ, format string, args ...any) *Anomaly {
+	r.Valid = false
+	r.Anomalies = append(r.Anomalies, Anomaly{Type: typ, Message: fmt.Sprintf(format, args...)})
+	return &r.Anomalies[len(r.Anomalies)-1]
+}
+
+// Anomaly represents a single problem found in a history.
+type Anomaly struct {
+	Type    string
+	Message string
+	Ops     []Op
+}
+
+// String returns a description of the anomaly.
+func (a Anomaly) String() string {
+	s := a.Type + ": " + a.Message
+	for _, op := range a.Ops {
+		s += "\n    " + op.String()
+	}
+	return s
+}
+
+// valueError returns an anomaly message for an op with an unexpected value type.
+func valueError(op Op, want string) string {
+	return fmt.Sprintf("expected %s value, got %T", want, op.Value)
+}
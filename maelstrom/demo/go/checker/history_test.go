@@ -0,0 +1,130 @@
+package checker_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+)
+
+func TestHistory_Pairs(t *testing.T) {
+	h := history(
+		invoke(1, "write", 1),
+		invoke(2, "read", nil),
+		ok(2, "read", 1),
+		invoke(2, "read", nil),
+		ok(1, "write", 1),
+	)
+
+	pairs := h.Pairs()
+	if got, want := len(pairs), 3; got != want {
+		t.Fatalf("len=%d, want %d", got, want)
+	} else if got, want := pairs[0].Completion.Index, 4; got != want {
+		t.Fatalf("pairs[0].Completion.Index=%d, want %d", got, want)
+	} else if got, want := pairs[1].Completion.Value, 1; got != want {
+		t.Fatalf("pairs[1].Completion.Value=%v, want %v", got, want)
+	}
+
+	// Operations which never complete are indeterminate.
+	if got, want := pairs[2].Completion.Type, checker.Info; got != want {
+		t.Fatalf("pairs[2].Completion.Type=%s, want %s", got, want)
+	} else if got, want := pairs[2].Completion.Index, -1; got != want {
+		t.Fatalf("pairs[2].Completion.Index=%d, want %d", got, want)
+	}
+}
+
+// Ensure the recorder classifies errors as definite or indeterminate failures.
+func TestRecorder_Do(t *testing.T) {
+	r := checker.NewRecorder()
+	if err := r.Do(1, "read", nil, func() (any, error) { return 5, nil }); err != nil {
+		t.Fatal(err)
+	}
+	r.Do(1, "cas", 1, func() (any, error) {
+		return nil, maelstrom.NewRPCError(maelstrom.PreconditionFailed, "no")
+	})
+	r.Do(2, "write", 2, func() (any, error) {
+		return nil, maelstrom.NewRPCError(maelstrom.Timeout, "timed out")
+	})
+	r.Do(2, "write", 3, func() (any, error) { return nil, errors.New("marker") })
+
+	h := r.History()
+	if got, want := len(h), 8; got != want {
+		t.Fatalf("len=%d, want %d", got, want)
+	}
+	for i, typ := range []checker.OpType{checker.Invoke, checker.OK, checker.Invoke, checker.Fail, checker.Invoke, checker.Info, checker.Invoke, checker.Info} {
+		if got, want := h[i].Type, typ; got != want {
+			t.Fatalf("h[%d].Type=%s, want %s", i, got, want)
+		} else if got, want := h[i].Index, i; got != want {
+			t.Fatalf("h[%d].Index=%d, want %d", i, got, want)
+		}
+	}
+	if got, want := h[1].Value, 5; got != want {
+		t.Fatalf("h[1].Value=%v, want %v", got, want)
+	} else if got, want := h[3].Value, 1; got != want {
+		t.Fatalf("h[3].Value=%v, want %v", got, want)
+	} else if got, want := maelstrom.ErrorCode(h[5].Error), maelstrom.Timeout; got != want {
+		t.Fatalf("h[5].Error code=%d, want %d", got, want)
+	}
+}
+
+func TestResult_String(t *testing.T) {
+	if got, want := (checker.Result{Valid: true}).String(), "valid"; got != want {
+		t.Fatalf("String()=%q, want %q", got, want)
+	}
+
+	res := checker.CheckCounter(history(
+		invoke(1, "add", 1),
+		ok(1, "add", 1),
+		invoke(1, "read", nil),
+		ok(1, "read", 3),
+	))
+	if s := res.String(); !strings.HasPrefix(s, "invalid: 1 anomalies\n- out-of-bounds: ") {
+		t.Fatalf("unexpected string: %s", s)
+	}
+}
+
+// history returns a history of ops with their indices set.
+func history(ops ...checker.Op) checker.History {
+	for i := range ops {
+		ops[i].Index = i
+	}
+	return ops
+}
+
+// invoke returns an invocation of f by process.
+func invoke(process int, f string, value any) checker.Op {
+	return checker.Op{Process: process, Type: checker.Invoke, F: f, Value: value}
+}
+
+// ok returns a successful completion of f by process.
+func ok(process int, f string, value any) checker.Op {
+	return checker.Op{Process: process, Type: checker.OK, F: f, Value: value}
+}
+
+// fail returns a failed completion of f by process.
+func fail(process int, f string, value any) checker.Op {
+	return checker.Op{Process: process, Type: checker.Fail, F: f, Value: value}
+}
+
+// info returns an indeterminate completion of f by process.
+func info(process int, f string, value any) checker.Op {
+	return checker.Op{Process: process, Type: checker.Info, F: f, Value: value}
+}
+
+// expectAnomalies fails the test if res does not contain exactly the given
+// anomaly types, in order.
+func expectAnomalies(tb testing.TB, res checker.Result, types ...string) {
+	tb.Helper()
+
+	var got []string
+	for _, a := range res.Anomalies {
+		got = append(got, a.Type)
+	}
+	if strings.Join(got, ",") != strings.Join(types, ",") {
+		tb.Fatalf("anomalies=%v, want %v\n%s", got, types, res)
+	} else if got, want := res.Valid, len(types) == 0; got != want {
+		tb.Fatalf("Valid=%v, want %v", got, want)
+	}
+}
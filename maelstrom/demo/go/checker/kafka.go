@@ -0,0 +1,150 @@
+package checker
+
+import (
+	"sort"
+
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+// KafkaSend is the value of a kafka "send" operation. Offset is only set on
+// successful completions.
+type KafkaSend struct {
+	Key    string
+	Msg    int
+	Offset int
+}
+
+// CheckKafka checks a kafka history. Send operations have an F of "send" and
+// a KafkaSend value. Poll operations have an F of "poll" and complete with a
+// map[string][]workload.KafkaRecord value. An operation with an F of "assign"
+// resets the offsets a process is expected to poll from next.
+//
+// The checker reports offsets which hold different messages, offsets which go
+// backwards within a poll or between successive operations of a process, and
+// lost writes: acknowledged sends which are never polled even though a
+// higher offset of the same key was polled.
+func CheckKafka(h History) Result {
+	res := Result{Valid: true}
+
+	type position struct {
+		key    string
+		offset int
+	}
+	type processKey struct {
+		process int
+		key     string
+	}
+	msgs := make(map[position]Op)          // first op observing each offset
+	polled := make(map[position]bool)      // offsets returned by polls
+	maxPolled := make(map[string]int)      // highest polled offset per key
+	lastSend := make(map[processKey]Op)    // last send op by process & key
+	lastPoll := make(map[processKey]Op)    // last poll op by process & key
+	lastOffset := make(map[processKey]int) // last polled offset by process & key
+	var acked []Op
+
+	// observe records that op saw msg at offset of key.
+	observe := func(op Op, key string, offset, msg int) {
+		pos := position{key, offset}
+		if prev, ok := msgs[pos]; !ok {
+			msgs[pos] = op
+		} else if prevMsg := kafkaMsgAt(prev, key, offset); prevMsg != msg {
+			res.add("inconsistent-offsets", "key %q offset %d holds both %d and %d", key, offset, prevMsg, msg).Ops = []Op{prev, op}
+		}
+	}
+
+	for _, p := range h.Pairs() {
+		op := p.Completion
+		if op.Type != OK && p.Invoke.F != "assign" {
+			continue
+		}
+
+		switch p.Invoke.F {
+		case "assign":
+			for pk := range lastPoll {
+				if pk.process == p.Invoke.Process {
+					delete(lastPoll, pk)
+					delete(lastOffset, pk)
+				}
+			}
+
+		case "send":
+			send, ok := op.Value.(KafkaSend)
+			if !ok {
+				res.add("malformed", valueError(op, "KafkaSend")).Ops = []Op{op}
+				continue
+			}
+			observe(op, send.Key, send.Offset, send.Msg)
+			acked = append(acked, op)
+
+			pk := processKey{op.Process, send.Key}
+			if prev, ok := lastSend[pk]; ok && prev.Value.(KafkaSend).Offset >= send.Offset {
+				res.add("send-nonmonotonic", "key %q offset went from %d to %d", send.Key, prev.Value.(KafkaSend).Offset, send.Offset).Ops = []Op{prev, op}
+			}
+			lastSend[pk] = op
+
+		case "poll":
+			records, ok := op.Value.(map[string][]workload.KafkaRecord)
+			if !ok {
+				res.add("malformed", valueError(op, "map[string][]workload.KafkaRecord")).Ops = []Op{op}
+				continue
+			}
+
+			for _, key := range sortedKeys(records) {
+				for i, r := range records[key] {
+					observe(op, key, r.Offset, r.Msg)
+					polled[position{key, r.Offset}] = true
+					if r.Offset > maxPolled[key] {
+						maxPolled[key] = r.Offset
+					}
+
+					if i > 0 && records[key][i-1].Offset >= r.Offset {
+						res.add("poll-internal-nonmonotonic", "key %q offset went from %d to %d", key, records[key][i-1].Offset, r.Offset).Ops = []Op{op}
+					}
+				}
+
+				if len(records[key]) == 0 {
+					continue
+				}
+				pk := processKey{op.Process, key}
+				if prev, ok := lastOffset[pk]; ok && records[key][0].Offset <= prev {
+					res.add("poll-external-nonmonotonic", "key %q offset went from %d to %d", key, prev, records[key][0].Offset).Ops = []Op{lastPoll[pk], op}
+				}
+				lastOffset[pk] = records[key][len(records[key])-1].Offset
+				lastPoll[pk] = op
+			}
+		}
+	}
+
+	for _, op := range acked {
+		send := op.Value.(KafkaSend)
+		if max, ok := maxPolled[send.Key]; ok && send.Offset < max && !polled[position{send.Key, send.Offset}] {
+			res.add("lost-write", "key %q offset %d was acknowledged but never polled, though offset %d was", send.Key, send.Offset, max).Ops = []Op{op}
+		}
+	}
+	return res
+}
+
+// kafkaMsgAt returns the message observed by op at offset of key.
+func kafkaMsgAt(op Op, key string, offset int) int {
+	switch v := op.Value.(type) {
+	case KafkaSend:
+		return v.Msg
+	case map[string][]workload.KafkaRecord:
+		for _, r := range v[key] {
+			if r.Offset == offset {
+				return r.Msg
+			}
+		}
+	}
+	return 0
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
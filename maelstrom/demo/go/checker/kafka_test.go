@@ -0,0 +1,70 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+func TestCheckKafka(t *testing.T) {
+	res := checker.CheckKafka(history(
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 10}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 10, Offset: 1}),
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 11}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 11, Offset: 2}),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 1, Msg: 10}}}),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 2, Msg: 11}}}),
+		invoke(2, "assign", nil),
+		ok(2, "assign", nil),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 1, Msg: 10}, {Offset: 2, Msg: 11}}}),
+	))
+	expectAnomalies(t, res)
+}
+
+// Ensure offsets holding different messages are reported.
+func TestCheckKafka_InconsistentOffsets(t *testing.T) {
+	res := checker.CheckKafka(history(
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 10}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 10, Offset: 1}),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 1, Msg: 20}}}),
+	))
+	expectAnomalies(t, res, "inconsistent-offsets")
+}
+
+// Ensure offsets which go backwards are reported.
+func TestCheckKafka_Nonmonotonic(t *testing.T) {
+	res := checker.CheckKafka(history(
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 10}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 10, Offset: 2}),
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 11}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 11, Offset: 1}),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 2, Msg: 10}, {Offset: 1, Msg: 11}}}),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 1, Msg: 11}}}),
+	))
+	expectAnomalies(t, res, "send-nonmonotonic", "poll-internal-nonmonotonic", "poll-external-nonmonotonic")
+}
+
+// Ensure acknowledged sends skipped by polls are reported as lost.
+func TestCheckKafka_LostWrite(t *testing.T) {
+	res := checker.CheckKafka(history(
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 10}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 10, Offset: 1}),
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 11}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 11, Offset: 2}),
+		invoke(1, "send", checker.KafkaSend{Key: "k1", Msg: 12}),
+		ok(1, "send", checker.KafkaSend{Key: "k1", Msg: 12, Offset: 3}),
+		invoke(2, "poll", nil),
+		ok(2, "poll", map[string][]workload.KafkaRecord{"k1": {{Offset: 1, Msg: 10}, {Offset: 3, Msg: 12}}}),
+	))
+	expectAnomalies(t, res, "lost-write")
+	if got, want := res.Anomalies[0].Ops[0].Value.(checker.KafkaSend).Offset, 2; got != want {
+		t.Fatalf("offset=%d, want %d", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KVOp is the value of a lin-kv operation. Read operations set Key on
+// invocation & Value on completion, write operations set Key & Value and cas
+// operations set Key, From & To.
+type KVOp struct {
+	Key   int
+	Value int
+	From  int
+	To    int
+}
+
+// CheckLinearizable checks that a lin-kv history is linearizable. Operations
+// have an F of "read", "write" or "cas" and a KVOp value. Every key is treated
+// as an independent register which initially does not exist.
+//
+// Failed operations are ignored. Indeterminate writes & cas operations may
+// take effect at any point after their invocation, or not at all.
+func CheckLinearizable(h History) Result {
+	res := Result{Valid: true}
+
+	byKey := make(map[int][]linOp)
+	var end int
+	for _, p := range h.Pairs() {
+		if p.Invoke.Index > end {
+			end = p.Invoke.Index
+		}
+		if p.Completion.Index > end {
+			end = p.Completion.Index
+		}
+
+		op := linOp{f: p.Invoke.F, call: p.Invoke.Index, ret: p.Completion.Index, pair: p}
+		switch p.Completion.Type {
+		case OK:
+			v, ok := p.Completion.Value.(KVOp)
+			if !ok {
+				res.add("malformed", valueError(p.Completion, "KVOp")).Ops = []Op{p.Completion}
+				continue
+			}
+			op.value = v
+		case Info:
+			// Indeterminate reads do not constrain the history.
+			if p.Invoke.F == "read" {
+				continue
+			}
+			v, ok := p.Invoke.Value.(KVOp)
+			if !ok {
+				res.add("malformed", valueError(p.Invoke, "KVOp")).Ops = []Op{p.Invoke}
+				continue
+			}
+			op.value, op.info = v, true
+		default:
+			continue
+		}
+
+		switch op.f {
+		case "read", "write", "cas":
+		default:
+			res.add("malformed", "unknown function %q", op.f).Ops = []Op{p.Invoke}
+			continue
+		}
+		byKey[op.value.Key] = append(byKey[op.value.Key], op)
+	}
+
+	keys := make([]int, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	for _, key := range keys {
+		ops := byKey[key]
+		for i := range ops {
+			if ops[i].info {
+				ops[i].ret = end + 1
+			}
+		}
+
+		s := newLinSearch(ops)
+		if s.search(linState{}) {
+			continue
+		}
+
+		pending := make([]Op, 0, len(s.stuck))
+		names := make([]string, 0, len(s.stuck))
+		for _, i := range s.stuck {
+			pending = append(pending, ops[i].pair.Completion)
+			names = append(names, ops[i].String())
+		}
+		res.add("nonlinearizable", "key %d: no linearization found after %d of %d operations; value was %s and could not apply %s",
+			key, s.deepest, len(ops), s.stuckState, strings.Join(names, ", ")).Ops = pending
+	}
+	return res
+}
+
+// linOp represents a single operation on a register.
+type linOp struct {
+	f     string
+	value KVOp
+	call  int  // index of invocation
+	ret   int  // index of completion, or past the end of the history if info
+	info  bool // true if the operation may not have taken effect
+	pair  Pair
+}
+
+// String returns a short description of the operation.
+func (op linOp) String() string {
+	switch op.f {
+	case "read":
+		return fmt.Sprintf("read %d", op.value.Value)
+	case "write":
+		return fmt.Sprintf("write %d", op.value.Value)
+	default:
+		return fmt.Sprintf("cas %d->%d", op.value.From, op.value.To)
+	}
+}
+
+// linState represents the state of a register.
+type linState struct {
+	exists bool
+	value  int
+}
+
+// String returns the register value, or "nil" if it does not exist.
+func (s linState) String() string {
+	if !s.exists {
+		return "nil"
+	}
+	return fmt.Sprint(s.value)
+}
+
+// step applies op to the register. Returns false if op could not have
+// returned its result from state s.
+func (s linState) step(op linOp) (linState, bool) {
+	switch op.f {
+	case "read":
+		return s, s.exists && s.value == op.value.Value
+	case "write":
+		return linState{exists: true, value: op.value.Value}, true
+	default:
+		if !s.exists || s.value != op.value.From {
+			return s, false
+		}
+		return linState{exists: true, value: op.value.To}, true
+	}
+}
+
+// linSearch performs a depth-first search for a linearization of the
+// operations on a single register. Operations are linearized one at a time
+// and an operation may only be linearized once every operation which
+// completed before its invocation has been linearized. Previously explored
+// combinations of linearized operations & register state are cached.
+type linSearch struct {
+	ops     []linOp
+	done    []uint64 // bitset of linearized operations
+	n       int      // number of linearized operations
+	remain  int      // number of unlinearized definite operations
+	visited map[string]struct{}
+
+	// Deepest point reached by the search, for reporting.
+	deepest    int
+	stuck      []int
+	stuckState linState
+}
+
+func newLinSearch(ops []linOp) *linSearch {
+	s := &linSearch{
+		ops:     ops,
+		done:    make([]uint64, (len(ops)+63)/64),
+		visited: make(map[string]struct{}),
+		deepest: -1,
+	}
+	for _, op := range ops {
+		if !op.info {
+			s.remain++
+		}
+	}
+	return s
+}
+
+func (s *linSearch) search(state linState) bool {
+	if s.remain == 0 {
+		return true
+	}
+
+	key := s.cacheKey(state)
+	if _, ok := s.visited[key]; ok {
+		return false
+	}
+	s.visited[key] = struct{}{}
+
+	// Only operations invoked before the earliest pending completion can be
+	// linearized next.
+	minRet := -1
+	for i, op := range s.ops {
+		if !s.isDone(i) && (minRet == -1 || op.ret < minRet) {
+			minRet = op.ret
+		}
+	}
+
+	var candidates []int
+	for i, op := range s.ops {
+		if s.isDone(i) || op.call > minRet {
+			continue
+		}
+		candidates = append(candidates, i)
+
+		next, ok := state.step(op)
+		if !ok {
+			continue
+		}
+
+		s.setDone(i, true)
+		if s.search(next) {
+			return true
+		}
+		s.setDone(i, false)
+	}
+
+	if s.n > s.deepest {
+		s.deepest, s.stuck, s.stuckState = s.n, candidates, state
+	}
+	return false
+}
+
+func (s *linSearch) isDone(i int) bool {
+	return s.done[i/64]&(1<<(i%64)) != 0
+}
+
+func (s *linSearch) setDone(i int, v bool) {
+	if v {
+		s.done[i/64] |= 1 << (i % 64)
+		s.n++
+	} else {
+		s.done[i/64] &^= 1 << (i % 64)
+		s.n--
+	}
+
+	if !s.ops[i].info {
+		if v {
+			s.remain--
+		} else {
+			s.remain++
+		}
+	}
+}
+
+// cacheKey returns a key identifying the set of linearized operations & state.
+func (s *linSearch) cacheKey(state linState) string {
+	var b strings.Builder
+	for _, w := range s.done {
+		fmt.Fprintf(&b, "%x.", w)
+	}
+	fmt.Fprintf(&b, "%t.%d", state.exists, state.value)
+	return b.String()
+}
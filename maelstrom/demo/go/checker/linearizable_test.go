@@ -0,0 +1,135 @@
+package checker_test
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+	"github.com/jepsen-io/maelstrom/demo/go/service"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+func TestCheckLinearizable(t *testing.T) {
+	// The read of 2 overlaps the write of 2 so it may be linearized after it.
+	res := checker.CheckLinearizable(history(
+		invoke(1, "write", checker.KVOp{Key: 1, Value: 1}),
+		ok(1, "write", checker.KVOp{Key: 1, Value: 1}),
+		invoke(1, "write", checker.KVOp{Key: 1, Value: 2}),
+		invoke(2, "read", checker.KVOp{Key: 1}),
+		ok(2, "read", checker.KVOp{Key: 1, Value: 2}),
+		ok(1, "write", checker.KVOp{Key: 1, Value: 2}),
+		invoke(2, "cas", checker.KVOp{Key: 1, From: 2, To: 3}),
+		ok(2, "cas", checker.KVOp{Key: 1, From: 2, To: 3}),
+		invoke(3, "read", checker.KVOp{Key: 2}),
+		fail(3, "read", checker.KVOp{Key: 2}),
+	))
+	expectAnomalies(t, res)
+}
+
+// Ensure a read of a stale value is reported.
+func TestCheckLinearizable_StaleRead(t *testing.T) {
+	res := checker.CheckLinearizable(history(
+		invoke(1, "write", checker.KVOp{Key: 1, Value: 1}),
+		ok(1, "write", checker.KVOp{Key: 1, Value: 1}),
+		invoke(1, "write", checker.KVOp{Key: 1, Value: 2}),
+		ok(1, "write", checker.KVOp{Key: 1, Value: 2}),
+		invoke(2, "read", checker.KVOp{Key: 1}),
+		ok(2, "read", checker.KVOp{Key: 1, Value: 1}),
+	))
+	expectAnomalies(t, res, "nonlinearizable")
+	if got, want := res.Anomalies[0].Ops[0].Process, 2; got != want {
+		t.Fatalf("process=%d, want %d", got, want)
+	}
+}
+
+// Ensure indeterminate writes may or may not take effect.
+func TestCheckLinearizable_Info(t *testing.T) {
+	t.Run("Applied", func(t *testing.T) {
+		res := checker.CheckLinearizable(history(
+			invoke(1, "write", checker.KVOp{Key: 1, Value: 1}),
+			info(1, "write", checker.KVOp{Key: 1, Value: 1}),
+			invoke(2, "read", checker.KVOp{Key: 1}),
+			ok(2, "read", checker.KVOp{Key: 1, Value: 1}),
+		))
+		expectAnomalies(t, res)
+	})
+
+	t.Run("NotApplied", func(t *testing.T) {
+		res := checker.CheckLinearizable(history(
+			invoke(1, "write", checker.KVOp{Key: 1, Value: 1}),
+			ok(1, "write", checker.KVOp{Key: 1, Value: 1}),
+			invoke(1, "cas", checker.KVOp{Key: 1, From: 1, To: 2}),
+			info(1, "cas", checker.KVOp{Key: 1, From: 1, To: 2}),
+			invoke(2, "read", checker.KVOp{Key: 1}),
+			ok(2, "read", checker.KVOp{Key: 1, Value: 1}),
+		))
+		expectAnomalies(t, res)
+	})
+
+	// An indeterminate write cannot take effect before it was invoked.
+	t.Run("BeforeInvoke", func(t *testing.T) {
+		res := checker.CheckLinearizable(history(
+			invoke(2, "read", checker.KVOp{Key: 1}),
+			ok(2, "read", checker.KVOp{Key: 1, Value: 1}),
+			invoke(1, "write", checker.KVOp{Key: 1, Value: 1}),
+			info(1, "write", checker.KVOp{Key: 1, Value: 1}),
+		))
+		expectAnomalies(t, res, "nonlinearizable")
+	})
+}
+
+// Ensure a history recorded against the lin-kv service is linearizable.
+func TestCheckLinearizable_LinKV(t *testing.T) {
+	net := simnet.NewNetwork()
+	net.AddService(maelstrom.LinKV, service.NewLinKV())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer net.Close()
+
+	r := checker.NewRecorder()
+	var wg sync.WaitGroup
+	for process := 0; process < 5; process++ {
+		process, c, rand := process, net.Client(), rand.New(rand.NewSource(int64(process)))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				key := rand.Intn(2)
+				switch rand.Intn(3) {
+				case 0:
+					r.Do(process, "read", checker.KVOp{Key: key}, func() (any, error) {
+						v, err := workload.KVRead(ctx, c, maelstrom.LinKV, key)
+						return checker.KVOp{Key: key, Value: v}, err
+					})
+				case 1:
+					op := checker.KVOp{Key: key, Value: rand.Intn(5)}
+					r.Do(process, "write", op, func() (any, error) {
+						return op, workload.KVWrite(ctx, c, maelstrom.LinKV, op.Key, op.Value)
+					})
+				case 2:
+					op := checker.KVOp{Key: key, From: rand.Intn(5), To: rand.Intn(5)}
+					r.Do(process, "cas", op, func() (any, error) {
+						return op, workload.KVCAS(ctx, c, maelstrom.LinKV, op.Key, op.From, op.To)
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	h := r.History()
+	if got, want := len(h), 500; got != want {
+		t.Fatalf("len=%d, want %d", got, want)
+	} else if n := len(h.Filter(func(op checker.Op) bool { return op.Type == checker.Info })); n != 0 {
+		t.Fatalf("unexpected indeterminate ops: %d", n)
+	}
+	expectAnomalies(t, checker.CheckLinearizable(h))
+}
@@ -0,0 +1,329 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+// CheckTxnRWRegister checks a txn-rw-register history for G0, G1a, G1b & G1c
+// anomalies. Transactions have an F of "txn" and a []workload.RWOp value.
+// Successful completions contain the values observed by reads.
+//
+// Like Maelstrom's checker, it assumes every value is written at most once
+// per key. Versions of a key are ordered by transactions which read a value
+// and then overwrite it, which yields write-write dependencies, while reads
+// of a written value yield write-read dependencies.
+func CheckTxnRWRegister(h History) Result {
+	res := Result{Valid: true}
+
+	type version struct {
+		key   int
+		value int
+	}
+
+	// Collect transactions & the versions they wrote. Only the final write
+	// of a key in a transaction is externally visible.
+	var txns []txn
+	writers := make(map[version]int)      // final version to txn index
+	intermediate := make(map[version]int) // overwritten version to txn index
+	failed := make(map[version]Op)        // version to failed txn
+	for _, p := range h.Pairs() {
+		if p.Invoke.F != "txn" {
+			continue
+		}
+
+		op, value := p.Completion, p.Completion.Value
+		if op.Type != OK {
+			value = p.Invoke.Value
+		}
+		ops, ok := value.([]workload.RWOp)
+		if !ok {
+			res.add("malformed", valueError(op, "[]workload.RWOp")).Ops = []Op{op}
+			continue
+		}
+
+		if op.Type == Fail {
+			for _, mop := range ops {
+				if mop.F == workload.FuncWrite && mop.Value != nil {
+					failed[version{mop.Key, *mop.Value}] = op
+				}
+			}
+			continue
+		}
+
+		i := len(txns)
+		txns = append(txns, txn{op: op, ops: ops})
+		final := make(map[int]int)
+		for _, mop := range ops {
+			if mop.F != workload.FuncWrite || mop.Value == nil {
+				continue
+			}
+			if prev, ok := final[mop.Key]; ok {
+				intermediate[version{mop.Key, prev}] = i
+			}
+			final[mop.Key] = *mop.Value
+		}
+		for k, v := range final {
+			writers[version{k, v}] = i
+		}
+	}
+
+	g := newTxnGraph(len(txns))
+	for i, t := range txns {
+		if t.op.Type != OK {
+			continue
+		}
+
+		// Track reads which precede any write of the same key in this txn.
+		written := make(map[int]bool)
+		external := make(map[int]*int)
+		for _, mop := range t.ops {
+			switch mop.F {
+			case workload.FuncRead:
+				if written[mop.Key] {
+					continue
+				}
+				if _, ok := external[mop.Key]; !ok {
+					external[mop.Key] = mop.Value
+				}
+				if mop.Value == nil {
+					continue
+				}
+
+				v := version{mop.Key, *mop.Value}
+				if op, ok := failed[v]; ok {
+					res.add("G1a", "txn read key %d = %d which was written by a failed txn", v.key, v.value).Ops = []Op{t.op, op}
+				} else if j, ok := intermediate[v]; ok && j != i {
+					res.add("G1b", "txn read key %d = %d which was overwritten within the txn which wrote it", v.key, v.value).Ops = []Op{t.op, txns[j].op}
+				} else if j, ok := writers[v]; ok && j != i {
+					g.link(j, i, wrEdge)
+				}
+
+			case workload.FuncWrite:
+				written[mop.Key] = true
+
+				// Writes follow the version read by the txn, if any.
+				if r, ok := external[mop.Key]; ok && r != nil {
+					if j, ok := writers[version{mop.Key, *r}]; ok && j != i {
+						g.link(j, i, wwEdge)
+					}
+				}
+			}
+		}
+	}
+
+	// G0: cycles consisting entirely of write-write dependencies.
+	g0 := make(map[int]bool)
+	for _, scc := range g.sccs(wwEdge) {
+		cycle := g.cycle(scc, wwEdge, wwEdge)
+		res.add("G0", "write cycle %s", g.describe(txns, cycle)).Ops = cycleOps(txns, cycle)
+		for _, v := range scc {
+			g0[v] = true
+		}
+	}
+
+	// G1c: cycles of write-write & write-read dependencies which include at
+	// least one write-read dependency. Components already reported as G0 are
+	// skipped.
+	for _, scc := range g.sccs(wwEdge | wrEdge) {
+		reported := true
+		for _, v := range scc {
+			reported = reported && g0[v]
+		}
+		if reported {
+			continue
+		}
+
+		if cycle := g.cycle(scc, wwEdge|wrEdge, wrEdge); cycle != nil {
+			res.add("G1c", "circular information flow %s", g.describe(txns, cycle)).Ops = cycleOps(txns, cycle)
+		}
+	}
+	return res
+}
+
+// txn represents a transaction which may have taken effect.
+type txn struct {
+	op  Op
+	ops []workload.RWOp
+}
+
+// cycleOps returns the completion ops of the transactions in a cycle.
+func cycleOps(txns []txn, cycle []int) []Op {
+	ops := make([]Op, len(cycle))
+	for i, t := range cycle {
+		ops[i] = txns[t].op
+	}
+	return ops
+}
+
+// edgeType is a bitmask of dependency types between two transactions.
+type edgeType int
+
+const (
+	wwEdge edgeType = 1 << iota // write-write dependency
+	wrEdge                      // write-read dependency
+)
+
+// String returns the dependency types of the edge, e.g. "ww,wr".
+func (e edgeType) String() string {
+	var a []string
+	if e&wwEdge != 0 {
+		a = append(a, "ww")
+	}
+	if e&wrEdge != 0 {
+		a = append(a, "wr")
+	}
+	return strings.Join(a, ",")
+}
+
+// txnGraph is a dependency graph between transactions.
+type txnGraph struct {
+	edges []map[int]edgeType
+}
+
+func newTxnGraph(n int) *txnGraph {
+	g := &txnGraph{edges: make([]map[int]edgeType, n)}
+	for i := range g.edges {
+		g.edges[i] = make(map[int]edgeType)
+	}
+	return g
+}
+
+// link adds a dependency of type typ from transaction a to b.
+func (g *txnGraph) link(a, b int, typ edgeType) {
+	g.edges[a][b] |= typ
+}
+
+// successors returns the sorted successors of v using edges matching mask.
+func (g *txnGraph) successors(v int, mask edgeType) []int {
+	var a []int
+	for w, typ := range g.edges[v] {
+		if typ&mask != 0 {
+			a = append(a, w)
+		}
+	}
+	sort.Ints(a)
+	return a
+}
+
+// cycle returns an example cycle within a strongly connected component using
+// edges matching mask. The cycle begins with an edge matching required.
+// Returns nil if no such cycle exists.
+func (g *txnGraph) cycle(scc []int, mask, required edgeType) []int {
+	in := make(map[int]bool, len(scc))
+	for _, v := range scc {
+		in[v] = true
+	}
+
+	for _, a := range scc {
+		for _, b := range g.successors(a, required) {
+			if !in[b] {
+				continue
+			}
+			if path := g.path(b, a, mask, in); path != nil {
+				return append([]int{a}, path[:len(path)-1]...)
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the shortest path from a to b within the vertices in, using
+// edges matching mask. Returns nil if no path exists.
+func (g *txnGraph) path(a, b int, mask edgeType, in map[int]bool) []int {
+	prev := map[int]int{a: -1}
+	queue := []int{a}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		if v == b {
+			var path []int
+			for ; v != -1; v = prev[v] {
+				path = append([]int{v}, path...)
+			}
+			return path
+		}
+
+		for _, w := range g.successors(v, mask) {
+			if _, ok := prev[w]; !ok && in[w] {
+				prev[w] = v
+				queue = append(queue, w)
+			}
+		}
+	}
+	return nil
+}
+
+// sccs returns the strongly connected components with more than one vertex
+// using Tarjan's algorithm.
+func (g *txnGraph) sccs(mask edgeType) [][]int {
+	index := make([]int, len(g.edges))
+	low := make([]int, len(g.edges))
+	onStack := make([]bool, len(g.edges))
+	for i := range index {
+		index[i] = -1
+	}
+
+	var stack []int
+	var sccs [][]int
+	var next int
+	var strongconnect func(v int)
+	strongconnect = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.successors(v, mask) {
+			if index[w] == -1 {
+				strongconnect(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onStack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+
+		if low[v] != index[v] {
+			return
+		}
+
+		var scc []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 {
+			sort.Ints(scc)
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for v := range g.edges {
+		if index[v] == -1 {
+			strongconnect(v)
+		}
+	}
+	return sccs
+}
+
+// describe returns a description of a cycle, e.g. "T3 -ww-> T5 -wr-> T3",
+// where transactions are identified by the index of their completion.
+func (g *txnGraph) describe(txns []txn, cycle []int) string {
+	var b strings.Builder
+	for i, v := range cycle {
+		w := cycle[(i+1)%len(cycle)]
+		fmt.Fprintf(&b, "T%d -%s-> ", txns[v].op.Index, g.edges[v][w])
+	}
+	fmt.Fprintf(&b, "T%d", txns[cycle[0]].op.Index)
+	return b.String()
+}
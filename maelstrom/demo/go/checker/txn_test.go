@@ -0,0 +1,85 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+func TestCheckTxnRWRegister(t *testing.T) {
+	res := checker.CheckTxnRWRegister(history(
+		invoke(1, "txn", txn(workload.RWRead(1), workload.RWWrite(1, 1))),
+		ok(1, "txn", txn(read(1, nil), workload.RWWrite(1, 1))),
+		invoke(2, "txn", txn(workload.RWRead(1), workload.RWWrite(1, 2))),
+		ok(2, "txn", txn(read(1, intp(1)), workload.RWWrite(1, 2))),
+		invoke(3, "txn", txn(workload.RWWrite(2, 1))),
+		fail(3, "txn", txn(workload.RWWrite(2, 1))),
+		invoke(3, "txn", txn(workload.RWRead(1), workload.RWRead(2))),
+		ok(3, "txn", txn(read(1, intp(2)), read(2, nil))),
+	))
+	expectAnomalies(t, res)
+}
+
+// Ensure reads of values written by failed transactions are reported.
+func TestCheckTxnRWRegister_G1a(t *testing.T) {
+	res := checker.CheckTxnRWRegister(history(
+		invoke(1, "txn", txn(workload.RWWrite(1, 1))),
+		fail(1, "txn", txn(workload.RWWrite(1, 1))),
+		invoke(2, "txn", txn(workload.RWRead(1))),
+		ok(2, "txn", txn(read(1, intp(1)))),
+	))
+	expectAnomalies(t, res, "G1a")
+}
+
+// Ensure reads of intermediate values are reported.
+func TestCheckTxnRWRegister_G1b(t *testing.T) {
+	res := checker.CheckTxnRWRegister(history(
+		invoke(1, "txn", txn(workload.RWWrite(1, 1), workload.RWWrite(1, 2))),
+		ok(1, "txn", txn(workload.RWWrite(1, 1), workload.RWWrite(1, 2))),
+		invoke(2, "txn", txn(workload.RWRead(1))),
+		ok(2, "txn", txn(read(1, intp(1)))),
+	))
+	expectAnomalies(t, res, "G1b")
+}
+
+// Ensure cycles of write-write dependencies are reported.
+func TestCheckTxnRWRegister_G0(t *testing.T) {
+	// T1 & T2 each overwrite the value written by the other.
+	res := checker.CheckTxnRWRegister(history(
+		invoke(1, "txn", txn(workload.RWRead(1), workload.RWWrite(1, 1))),
+		ok(1, "txn", txn(read(1, intp(2)), workload.RWWrite(1, 1))),
+		invoke(2, "txn", txn(workload.RWRead(1), workload.RWWrite(1, 2))),
+		ok(2, "txn", txn(read(1, intp(1)), workload.RWWrite(1, 2))),
+	))
+	expectAnomalies(t, res, "G0")
+	if got, want := res.Anomalies[0].Message, "write cycle T1 -ww,wr-> T3 -ww,wr-> T1"; got != want {
+		t.Fatalf("message=%q, want %q", got, want)
+	}
+}
+
+// Ensure cycles of write-read dependencies are reported.
+func TestCheckTxnRWRegister_G1c(t *testing.T) {
+	// T1 observes T2's write while T2 observes T1's write.
+	res := checker.CheckTxnRWRegister(history(
+		invoke(1, "txn", txn(workload.RWWrite(1, 1), workload.RWRead(2))),
+		invoke(2, "txn", txn(workload.RWWrite(2, 1), workload.RWRead(1))),
+		ok(1, "txn", txn(workload.RWWrite(1, 1), read(2, intp(1)))),
+		ok(2, "txn", txn(workload.RWWrite(2, 1), read(1, intp(1)))),
+	))
+	expectAnomalies(t, res, "G1c")
+	if got, want := res.Anomalies[0].Message, "circular information flow T2 -wr-> T3 -wr-> T2"; got != want {
+		t.Fatalf("message=%q, want %q", got, want)
+	}
+}
+
+// txn returns a transaction consisting of ops.
+func txn(ops ...workload.RWOp) []workload.RWOp { return ops }
+
+// read returns a completed read of key which observed value.
+func read(key int, value *int) workload.RWOp {
+	return workload.RWOp{F: workload.FuncRead, Key: key, Value: value}
+}
+
+// intp returns a pointer to v.
+func intp(v int) *int { return &v }
@@ -0,0 +1,74 @@
+package maelstrom_test
+
+import (
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestManualClock(t *testing.T) {
+	t.Run("Advance", func(t *testing.T) {
+		start := time.Unix(1000, 0)
+		c := maelstrom.NewManualClock(start)
+
+		t1 := c.NewTimer(2 * time.Second)
+		t2 := c.NewTimer(1 * time.Second)
+		t3 := c.NewTimer(3 * time.Second)
+		if got, want := c.Timers(), 3; got != want {
+			t.Fatalf("timers=%d, want %d", got, want)
+		}
+
+		c.Advance(2 * time.Second)
+		if got, want := c.Now(), start.Add(2*time.Second); !got.Equal(want) {
+			t.Fatalf("now=%s, want %s", got, want)
+		}
+		for _, tm := range []maelstrom.Timer{t1, t2} {
+			select {
+			case v := <-tm.C():
+				if want := start.Add(2 * time.Second); !v.Equal(want) {
+					t.Fatalf("fired=%s, want %s", v, want)
+				}
+			default:
+				t.Fatal("expected timer to fire")
+			}
+		}
+		select {
+		case <-t3.C():
+			t.Fatal("unexpected timer fire")
+		default:
+		}
+
+		if got, want := c.Timers(), 1; got != want {
+			t.Fatalf("timers=%d, want %d", got, want)
+		} else if t1.Stop() {
+			t.Fatal("expected fired timer to not stop")
+		} else if !t3.Stop() {
+			t.Fatal("expected pending timer to stop")
+		} else if got, want := c.Timers(), 0; got != want {
+			t.Fatalf("timers=%d, want %d", got, want)
+		}
+	})
+
+	t.Run("Immediate", func(t *testing.T) {
+		c := maelstrom.NewManualClock(time.Unix(0, 0))
+		select {
+		case <-c.NewTimer(0).C():
+		default:
+			t.Fatal("expected timer to fire")
+		}
+	})
+}
+
+func TestSystemClock(t *testing.T) {
+	c := maelstrom.SystemClock{}
+	if d := time.Since(c.Now()); d < 0 || d > time.Second {
+		t.Fatalf("unexpected clock skew: %s", d)
+	}
+
+	select {
+	case <-c.NewTimer(time.Millisecond).C():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for timer")
+	}
+}
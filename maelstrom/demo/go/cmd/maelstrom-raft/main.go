@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/raft"
+)
+
+func main() {
+	n := maelstrom.NewNode()
+
+	// Replicate a key/value store for the lin-kv workload using Raft.
+	r := raft.New(n, raft.NewKV(), raft.DefaultConfig())
+	for _, typ := range []string{"read", "write", "cas"} {
+		r.HandleClient(typ)
+	}
+
+	// Execute the node's message loop. This will run until STDIN is closed.
+	if err := n.Run(); err != nil {
+		log.Printf("ERROR: %s", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+// Package detector implements failure detectors for the peers of a
+// maelstrom.Node. A Detector estimates from the arrival times of heartbeats
+// whether a peer has failed, and a Monitor exchanges heartbeats between the
+// nodes of a cluster & tracks which peers are currently suspected.
+//
+// Detectors are unreliable by nature: a slow or partitioned peer is
+// indistinguishable from a crashed one. Suspicion should be used to avoid
+// wasting effort on peers which are unlikely to respond, not for safety.
+package detector
+
+import (
+	"sync"
+	"time"
+)
+
+// Detector estimates whether peers have failed. Implementations must be safe
+// for concurrent use.
+type Detector interface {
+	// Heartbeat records that a message was received from peer at time t.
+	Heartbeat(peer string, t time.Time)
+
+	// Suspected returns true if peer is suspected to have failed at time
+	// now. Peers which have never sent a heartbeat are not suspected.
+	Suspected(peer string, now time.Time) bool
+}
+
+// Timeout is a Detector which suspects a peer once no heartbeat has been
+// received from it for a fixed duration.
+type Timeout struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	last    map[string]time.Time
+}
+
+// NewTimeout returns a detector which suspects peers after timeout.
+func NewTimeout(timeout time.Duration) *Timeout {
+	return &Timeout{
+		timeout: timeout,
+		last:    make(map[string]time.Time),
+	}
+}
+
+// Heartbeat records a heartbeat from peer at time t.
+func (d *Timeout) Heartbeat(peer string, t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t.After(d.last[peer]) {
+		d.last[peer] = t
+	}
+}
+
+// Suspected returns true if no heartbeat has been received from peer within
+// the timeout.
+func (d *Timeout) Suspected(peer string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	last, ok := d.last[peer]
+	return ok && now.Sub(last) > d.timeout
+}
@@ -0,0 +1,31 @@
+package detector_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jepsen-io/maelstrom/demo/go/detector"
+)
+
+func TestTimeout(t *testing.T) {
+	start := time.Unix(0, 0)
+	d := detector.NewTimeout(time.Second)
+
+	if d.Suspected("n2", start.Add(time.Hour)) {
+		t.Fatal("expected unknown peer to not be suspected")
+	}
+
+	d.Heartbeat("n2", start)
+	if d.Suspected("n2", start.Add(time.Second)) {
+		t.Fatal("expected peer to be alive within timeout")
+	} else if !d.Suspected("n2", start.Add(time.Second+1)) {
+		t.Fatal("expected peer to be suspected after timeout")
+	}
+
+	// Ensure late heartbeats do not move the last heartbeat back in time.
+	d.Heartbeat("n2", start.Add(2*time.Second))
+	d.Heartbeat("n2", start)
+	if d.Suspected("n2", start.Add(3*time.Second)) {
+		t.Fatal("expected peer to be alive after heartbeat")
+	}
+}
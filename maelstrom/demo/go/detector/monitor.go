@@ -0,0 +1,227 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Event represents a change in the status of a peer.
+type Event struct {
+	Peer  string
+	Alive bool
+	Time  time.Time
+}
+
+// Monitor sends heartbeats to every other node in the cluster and uses a
+// Detector to track which peers are suspected to have failed. Only heartbeats
+// are recorded, as bursts of other traffic would skew the intervals seen by
+// the detector.
+type Monitor struct {
+	node     *maelstrom.Node
+	detector Detector
+	interval time.Duration
+
+	mu        sync.Mutex
+	suspected map[string]bool
+	changed   chan struct{} // closed & replaced when a status changes
+	onChange  []func(Event)
+}
+
+// NewMonitor returns a monitor which sends heartbeats to the peers of n every
+// interval, once n is initialized. Registers a "heartbeat" handler & an
+// inbound middleware on n so it must be called before n.Run().
+func NewMonitor(n *maelstrom.Node, d Detector, interval time.Duration) *Monitor {
+	m := &Monitor{
+		node:      n,
+		detector:  d,
+		interval:  interval,
+		suspected: make(map[string]bool),
+		changed:   make(chan struct{}),
+	}
+
+	// Heartbeats are recorded by the middleware as they are received.
+	n.Handle("heartbeat", func(msg maelstrom.Message) error { return nil })
+	n.Use(m.observe)
+	n.OnInit(m.start)
+	return m
+}
+
+// Alive returns true if peer is not currently suspected.
+func (m *Monitor) Alive(peer string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.suspected[peer]
+}
+
+// Suspected returns the sorted IDs of the peers currently suspected.
+func (m *Monitor) Suspected() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var a []string
+	for peer, suspected := range m.suspected {
+		if suspected {
+			a = append(a, peer)
+		}
+	}
+	sort.Strings(a)
+	return a
+}
+
+// AlivePeers returns the other nodes in the cluster which are not currently
+// suspected, in the order of Node.NodeIDs().
+func (m *Monitor) AlivePeers() []string {
+	var a []string
+	for _, peer := range m.peers() {
+		if m.Alive(peer) {
+			a = append(a, peer)
+		}
+	}
+	return a
+}
+
+// OnChange registers fn to be called whenever a peer becomes suspected or
+// alive again. Callbacks may be called concurrently and should not block.
+func (m *Monitor) OnChange(fn func(Event)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// WaitAlive blocks until peer is not suspected. Returns an error if ctx is
+// done first. This can be used to park traffic for a suspected peer instead
+// of repeatedly retrying it.
+func (m *Monitor) WaitAlive(ctx context.Context, peer string) error {
+	for {
+		m.mu.Lock()
+		suspected, changed := m.suspected[peer], m.changed
+		m.mu.Unlock()
+
+		if !suspected {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Outbound returns a middleware for Node.UseOutbound which rejects requests
+// & one-way messages to suspected peers with a TemporarilyUnavailable error,
+// so that RetryRPC backs off instead of waiting for a timeout. Replies &
+// heartbeats are always sent.
+func (m *Monitor) Outbound() maelstrom.Middleware {
+	return func(next maelstrom.HandlerFunc) maelstrom.HandlerFunc {
+		return func(msg maelstrom.Message) error {
+			if m.Alive(msg.Dest) {
+				return next(msg)
+			}
+
+			var body maelstrom.MessageBody
+			if err := json.Unmarshal(msg.Body, &body); err != nil {
+				return err
+			} else if body.InReplyTo != 0 || body.Type == "heartbeat" {
+				return next(msg)
+			}
+			return maelstrom.NewRPCError(maelstrom.TemporarilyUnavailable, fmt.Sprintf("peer %s is suspected", msg.Dest))
+		}
+	}
+}
+
+// observe is an inbound middleware which records heartbeats from peers.
+func (m *Monitor) observe(next maelstrom.HandlerFunc) maelstrom.HandlerFunc {
+	return func(msg maelstrom.Message) error {
+		if msg.Type() == "heartbeat" && m.isPeer(msg.Src) {
+			now := m.node.Clock.Now()
+			m.detector.Heartbeat(msg.Src, now)
+			m.update(msg.Src, now)
+		}
+		return next(msg)
+	}
+}
+
+// start begins sending heartbeats once the node is initialized. Peers are
+// given an initial heartbeat so that peers which never respond are
+// eventually suspected.
+func (m *Monitor) start(ctx context.Context) error {
+	now := m.node.Clock.Now()
+	for _, peer := range m.peers() {
+		m.detector.Heartbeat(peer, now)
+	}
+
+	m.node.Every(m.interval, 0, m.tick)
+	return nil
+}
+
+// tick sends heartbeats to all peers & updates their status.
+func (m *Monitor) tick(ctx context.Context) error {
+	for _, peer := range m.peers() {
+		if err := m.node.Send(peer, maelstrom.MessageBody{Type: "heartbeat"}); err != nil {
+			log.Printf("heartbeat error: %s", err)
+		}
+	}
+
+	now := m.node.Clock.Now()
+	for _, peer := range m.peers() {
+		m.update(peer, now)
+	}
+	return nil
+}
+
+// update consults the detector for the status of peer & notifies callbacks
+// if it has changed.
+func (m *Monitor) update(peer string, now time.Time) {
+	suspected := m.detector.Suspected(peer, now)
+
+	m.mu.Lock()
+	if m.suspected[peer] == suspected {
+		m.mu.Unlock()
+		return
+	}
+	m.suspected[peer] = suspected
+	close(m.changed)
+	m.changed = make(chan struct{})
+	fns := m.onChange
+	m.mu.Unlock()
+
+	if suspected {
+		log.Printf("Peer %s suspected", peer)
+	} else {
+		log.Printf("Peer %s alive", peer)
+	}
+
+	ev := Event{Peer: peer, Alive: !suspected, Time: now}
+	for _, fn := range fns {
+		fn(ev)
+	}
+}
+
+// peers returns the other nodes in the cluster.
+func (m *Monitor) peers() []string {
+	var a []string
+	for _, id := range m.node.NodeIDs() {
+		if id != m.node.ID() {
+			a = append(a, id)
+		}
+	}
+	return a
+}
+
+func (m *Monitor) isPeer(id string) bool {
+	for _, peer := range m.peers() {
+		if peer == id {
+			return true
+		}
+	}
+	return false
+}
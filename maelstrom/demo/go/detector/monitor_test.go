@@ -0,0 +1,184 @@
+package detector_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/detector"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+)
+
+func TestMonitor(t *testing.T) {
+	clock := maelstrom.NewManualClock(time.Unix(0, 0))
+	net := simnet.NewNetwork()
+
+	var monitors []*detector.Monitor
+	var nodes []*maelstrom.Node
+	for _, id := range []string{"n1", "n2", "n3"} {
+		n := maelstrom.NewNode()
+		n.Clock = clock
+		m := detector.NewMonitor(n, detector.NewTimeout(3*time.Second), time.Second)
+		n.UseOutbound(m.Outbound())
+		n.Handle("foo", func(msg maelstrom.Message) error { return nil })
+
+		net.AddNode(id, n)
+		nodes, monitors = append(nodes, n), append(monitors, m)
+	}
+	n1, m1 := nodes[0], monitors[0]
+
+	var mu sync.Mutex
+	var events []detector.Event
+	m1.OnChange(func(ev detector.Event) {
+		// Ignore n2, which may briefly be suspected if its heartbeats are
+		// delivered late relative to the clock.
+		if ev.Peer != "n3" {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer net.Close()
+
+	// advance moves the clock forward one interval once every node is
+	// waiting to send its next heartbeats.
+	advance := func(times int) {
+		t.Helper()
+		for i := 0; i < times; i++ {
+			waitUntil(t, func() bool { return clock.Timers() == 3 })
+			clock.Advance(time.Second)
+		}
+	}
+
+	advance(2)
+	if got := m1.Suspected(); len(got) != 0 {
+		t.Fatalf("unexpected suspected peers: %v", got)
+	}
+
+	// Isolate n3 until n1 suspects it.
+	net.Partition([]string{"n1", "n2"}, []string{"n3"})
+	for i := 0; m1.Alive("n3"); i++ {
+		if i > 20 {
+			t.Fatal("expected n3 to be suspected")
+		}
+		advance(1)
+	}
+	if got := m1.Suspected(); !reflect.DeepEqual(got, []string{"n3"}) && !reflect.DeepEqual(got, []string{"n2", "n3"}) {
+		t.Fatalf("unexpected suspected peers: %v", got)
+	}
+
+	// Ensure requests to suspected peers are rejected.
+	if err := n1.Send("n3", maelstrom.MessageBody{Type: "foo"}); maelstrom.ErrorCode(err) != maelstrom.TemporarilyUnavailable {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := n1.Send("n3", maelstrom.MessageBody{Type: "heartbeat"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure waiters are released once the peer recovers.
+	alive := make(chan error, 1)
+	go func() { alive <- m1.WaitAlive(ctx, "n3") }()
+
+	net.Heal()
+	for i := 0; !m1.Alive("n3"); i++ {
+		if i > 20 {
+			t.Fatal("expected n3 to be alive")
+		}
+		advance(1)
+	}
+	if err := <-alive; err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := len(events), 2; got != want {
+		t.Fatalf("events=%v, want %d", events, want)
+	} else if ev := events[0]; ev.Alive {
+		t.Fatalf("unexpected event: %+v", ev)
+	} else if ev := events[1]; !ev.Alive {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+}
+
+// Ensure a burst of ordinary traffic does not skew the heartbeat intervals
+// seen by the detector, which would cause a peer to be suspected before its
+// next heartbeat is due.
+func TestMonitor_Traffic(t *testing.T) {
+	clock := maelstrom.NewManualClock(time.Unix(0, 0))
+	net := simnet.NewNetwork()
+
+	n1 := maelstrom.NewNode()
+	n1.Clock = clock
+	m1 := detector.NewMonitor(n1, detector.NewPhiAccrual(time.Second), time.Second)
+	received := make(chan struct{}, 1)
+	n1.Handle("foo", func(msg maelstrom.Message) error {
+		received <- struct{}{}
+		return nil
+	})
+	net.AddNode("n1", n1)
+
+	// n2 sends no heartbeats of its own.
+	n2 := maelstrom.NewNode()
+	n2.Clock = clock
+	net.AddNode("n2", n2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer net.Close()
+
+	// Send a burst of messages from n2 a millisecond apart, filling the
+	// detector's window if they were counted as heartbeats.
+	waitUntil(t, func() bool { return clock.Timers() == 1 })
+	for i := 0; i < 150; i++ {
+		if err := n2.Send("n1", maelstrom.MessageBody{Type: "foo"}); err != nil {
+			t.Fatal(err)
+		}
+		<-received
+		clock.Advance(time.Millisecond)
+	}
+
+	// Ensure n2 is not suspected one heartbeat interval after the start.
+	clock.Advance(850 * time.Millisecond)
+	waitUntil(t, func() bool { return clock.Timers() == 1 })
+	if !m1.Alive("n2") {
+		t.Fatal("expected n2 to be alive")
+	}
+}
+
+func TestMonitor_WaitAlive(t *testing.T) {
+	n := maelstrom.NewNode()
+	m := detector.NewMonitor(n, detector.NewTimeout(time.Second), time.Second)
+
+	// Unknown peers are not suspected.
+	if err := m.WaitAlive(context.Background(), "n2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitUntil polls fn until it returns true or fails the test after a timeout.
+func waitUntil(tb testing.TB, fn func() bool) {
+	tb.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for !fn() {
+		select {
+		case <-timeout:
+			tb.Fatal("timeout waiting for condition")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package detector
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// PhiAccrual is a Detector which computes a suspicion level, phi, for each
+// peer from the distribution of its heartbeat intervals. A phi of 1 means a
+// 10% chance that suspecting the peer is a mistake, a phi of 2 means a 1%
+// chance and so on. Peers are suspected once phi reaches Threshold.
+//
+// Unlike a fixed timeout, the detector adapts to the observed heartbeat
+// intervals of each peer, such as those caused by network latency.
+type PhiAccrual struct {
+	mu      sync.Mutex
+	windows map[string]*arrivalWindow
+
+	// Phi at which a peer is suspected.
+	Threshold float64
+
+	// Number of heartbeat intervals used to estimate the distribution.
+	WindowSize int
+
+	// Lower bound of the standard deviation, so that perfectly regular
+	// heartbeats do not make the detector overly sensitive.
+	MinStdDev time.Duration
+
+	// Additional delay which is tolerated beyond the mean interval, such as
+	// for pauses of the peer.
+	AcceptablePause time.Duration
+
+	// Expected heartbeat interval, used until intervals have been observed.
+	FirstHeartbeatEstimate time.Duration
+}
+
+// NewPhiAccrual returns a detector for heartbeats sent every interval with a
+// threshold of 8.
+func NewPhiAccrual(interval time.Duration) *PhiAccrual {
+	return &PhiAccrual{
+		windows:                make(map[string]*arrivalWindow),
+		Threshold:              8,
+		WindowSize:             100,
+		MinStdDev:              interval / 10,
+		FirstHeartbeatEstimate: interval,
+	}
+}
+
+// Heartbeat records a heartbeat from peer at time t.
+func (d *PhiAccrual) Heartbeat(peer string, t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w := d.windows[peer]
+	if w == nil {
+		// Seed the window with intervals around the first estimate.
+		w = &arrivalWindow{last: t}
+		mean, stddev := float64(d.FirstHeartbeatEstimate), float64(d.FirstHeartbeatEstimate)/4
+		w.add(mean-stddev, d.WindowSize)
+		w.add(mean+stddev, d.WindowSize)
+		d.windows[peer] = w
+		return
+	}
+
+	if t.After(w.last) {
+		w.add(float64(t.Sub(w.last)), d.WindowSize)
+		w.last = t
+	}
+}
+
+// Phi returns the suspicion level of peer at time now. Returns zero for
+// peers which have never sent a heartbeat.
+func (d *PhiAccrual) Phi(peer string, now time.Time) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w := d.windows[peer]
+	if w == nil {
+		return 0
+	}
+
+	mean, stddev := w.stats()
+	mean += float64(d.AcceptablePause)
+	if min := float64(d.MinStdDev); stddev < min {
+		stddev = min
+	}
+	return phi(float64(now.Sub(w.last)), mean, stddev)
+}
+
+// Suspected returns true if the phi of peer has reached the threshold.
+func (d *PhiAccrual) Suspected(peer string, now time.Time) bool {
+	return d.Phi(peer, now) >= d.Threshold
+}
+
+// phi returns -log10 of the probability that a heartbeat arrives later than
+// elapsed, using a logistic approximation of the normal distribution.
+func phi(elapsed, mean, stddev float64) float64 {
+	if stddev <= 0 {
+		if elapsed > mean {
+			return math.Inf(1)
+		}
+		return 0
+	}
+
+	y := (elapsed - mean) / stddev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// arrivalWindow holds the most recent heartbeat intervals of a peer, in
+// nanoseconds.
+type arrivalWindow struct {
+	last      time.Time
+	intervals []float64
+	sum, sq   float64
+}
+
+func (w *arrivalWindow) add(interval float64, size int) {
+	if size > 0 && len(w.intervals) >= size {
+		old := w.intervals[0]
+		w.intervals = w.intervals[1:]
+		w.sum -= old
+		w.sq -= old * old
+	}
+	w.intervals = append(w.intervals, interval)
+	w.sum += interval
+	w.sq += interval * interval
+}
+
+// stats returns the mean & standard deviation of the intervals.
+func (w *arrivalWindow) stats() (mean, stddev float64) {
+	n := float64(len(w.intervals))
+	mean = w.sum / n
+	variance := w.sq/n - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
@@ -0,0 +1,64 @@
+package detector_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jepsen-io/maelstrom/demo/go/detector"
+)
+
+func TestPhiAccrual(t *testing.T) {
+	start := time.Unix(0, 0)
+	d := detector.NewPhiAccrual(100 * time.Millisecond)
+
+	if got := d.Phi("n2", start); got != 0 {
+		t.Fatalf("phi=%f, want 0", got)
+	}
+
+	// Send regular heartbeats every 100ms.
+	now := start
+	for i := 0; i < 20; i++ {
+		d.Heartbeat("n2", now)
+		now = now.Add(100 * time.Millisecond)
+	}
+	last := now.Add(-100 * time.Millisecond)
+
+	// Ensure phi grows as the next heartbeat is overdue.
+	prev := -1.0
+	for _, elapsed := range []time.Duration{50, 100, 150, 200, 300} {
+		got := d.Phi("n2", last.Add(elapsed*time.Millisecond))
+		if got <= prev {
+			t.Fatalf("phi at %dms=%f, want more than %f", elapsed, got, prev)
+		}
+		prev = got
+	}
+
+	if d.Suspected("n2", last.Add(100*time.Millisecond)) {
+		t.Fatal("expected peer to be alive at mean interval")
+	} else if !d.Suspected("n2", last.Add(time.Second)) {
+		t.Fatal("expected peer to be suspected after 10 missed heartbeats")
+	}
+}
+
+// Ensure the detector adapts to irregular heartbeats.
+func TestPhiAccrual_Adaptive(t *testing.T) {
+	start := time.Unix(0, 0)
+	regular, irregular := detector.NewPhiAccrual(100*time.Millisecond), detector.NewPhiAccrual(100*time.Millisecond)
+
+	now := start
+	for i := 0; i < 50; i++ {
+		regular.Heartbeat("n2", start.Add(time.Duration(i)*100*time.Millisecond))
+
+		irregular.Heartbeat("n2", now)
+		if i%2 == 0 {
+			now = now.Add(20 * time.Millisecond)
+		} else {
+			now = now.Add(180 * time.Millisecond)
+		}
+	}
+
+	at := 250 * time.Millisecond
+	if a, b := regular.Phi("n2", start.Add(49*100*time.Millisecond+at)), irregular.Phi("n2", now.Add(-180*time.Millisecond+at)); a <= b {
+		t.Fatalf("expected regular phi %f to exceed irregular phi %f", a, b)
+	}
+}
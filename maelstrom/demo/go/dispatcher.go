@@ -0,0 +1,189 @@
+package maelstrom
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned by a Dispatcher when it cannot accept more
+// messages. The node replies to the message with a TemporarilyUnavailable
+// error.
+var ErrQueueFull = errors.New("dispatch queue full")
+
+// Dispatcher schedules the execution of inbound message handlers.
+//
+// Dispatchers are only used for requests. Callbacks for RPC responses always
+// execute in their own goroutine so that a handler blocked on SyncRPC() can
+// never prevent its own response from being processed.
+type Dispatcher interface {
+	// Dispatch schedules fn to handle msg. Returns ErrQueueFull if the
+	// message cannot be accepted.
+	Dispatch(msg Message, fn func()) error
+
+	// Close waits for all scheduled functions to complete and releases any
+	// resources. It is called once by the node after Run() has finished
+	// reading messages.
+	Close()
+}
+
+// GoroutineDispatcher executes every message in a separate goroutine. This
+// is the default dispatcher.
+type GoroutineDispatcher struct {
+	wg sync.WaitGroup
+}
+
+// NewGoroutineDispatcher returns a new instance of GoroutineDispatcher.
+func NewGoroutineDispatcher() *GoroutineDispatcher {
+	return &GoroutineDispatcher{}
+}
+
+// Dispatch executes fn in a new goroutine.
+func (d *GoroutineDispatcher) Dispatch(msg Message, fn func()) error {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		fn()
+	}()
+	return nil
+}
+
+// Close waits for all executing functions to complete.
+func (d *GoroutineDispatcher) Close() {
+	d.wg.Wait()
+}
+
+// PoolDispatcher executes messages on a fixed number of worker goroutines.
+// Messages wait in a bounded queue until a worker is available.
+type PoolDispatcher struct {
+	once    sync.Once
+	wg      sync.WaitGroup
+	workers int
+	slots   chan struct{} // limits executing & queued functions
+	queue   chan func()
+}
+
+// NewPoolDispatcher returns a dispatcher which executes messages on the given
+// number of workers. Up to queueSize messages can wait for a worker before
+// further messages are rejected with ErrQueueFull. A queueSize of zero means
+// messages are only accepted while a worker is idle.
+func NewPoolDispatcher(workers, queueSize int) *PoolDispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	return &PoolDispatcher{
+		workers: workers,
+		slots:   make(chan struct{}, workers+queueSize),
+		queue:   make(chan func(), workers+queueSize),
+	}
+}
+
+// NewActorDispatcher returns a dispatcher which executes messages one at a
+// time in the order they are received. This allows handlers to share state
+// without locking. Up to queueSize messages can wait before further messages
+// are rejected with ErrQueueFull.
+func NewActorDispatcher(queueSize int) *PoolDispatcher {
+	return NewPoolDispatcher(1, queueSize)
+}
+
+// Dispatch adds fn to the queue. Returns ErrQueueFull if the queue is full.
+func (d *PoolDispatcher) Dispatch(msg Message, fn func()) error {
+	d.once.Do(d.start)
+
+	select {
+	case d.slots <- struct{}{}:
+		d.queue <- fn
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close waits for queued functions to complete and stops the workers.
+func (d *PoolDispatcher) Close() {
+	d.once.Do(d.start)
+	close(d.queue)
+	d.wg.Wait()
+}
+
+// start starts the worker goroutines.
+func (d *PoolDispatcher) start() {
+	d.wg.Add(d.workers)
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			defer d.wg.Done()
+			for fn := range d.queue {
+				fn()
+				<-d.slots
+			}
+		}()
+	}
+}
+
+// KeyedDispatcher executes messages which share a key serially, in the order
+// they are received. Messages with different keys execute concurrently.
+type KeyedDispatcher struct {
+	mu        sync.Mutex
+	wg        sync.WaitGroup
+	key       func(Message) string
+	queueSize int
+	queues    map[string][]func() // pending functions by key
+}
+
+// NewKeyedDispatcher returns a dispatcher which orders messages by the key
+// returned by fn. Up to queueSize messages per key can wait before further
+// messages for that key are rejected with ErrQueueFull. A queueSize of zero
+// means the queues are unbounded.
+func NewKeyedDispatcher(fn func(msg Message) string, queueSize int) *KeyedDispatcher {
+	return &KeyedDispatcher{
+		key:       fn,
+		queueSize: queueSize,
+		queues:    make(map[string][]func()),
+	}
+}
+
+// Dispatch adds fn to the queue for the key of msg. A goroutine is started to
+// drain the queue if one is not already running for the key.
+func (d *KeyedDispatcher) Dispatch(msg Message, fn func()) error {
+	key := d.key(msg)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	q, running := d.queues[key]
+	if d.queueSize > 0 && len(q) >= d.queueSize {
+		return ErrQueueFull
+	}
+	d.queues[key] = append(q, fn)
+
+	if !running {
+		d.wg.Add(1)
+		go d.drain(key)
+	}
+	return nil
+}
+
+// drain executes queued functions for key until its queue is empty.
+func (d *KeyedDispatcher) drain(key string) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		q := d.queues[key]
+		if len(q) == 0 {
+			delete(d.queues, key)
+			d.mu.Unlock()
+			return
+		}
+		fn := q[0]
+		q[0] = nil
+		d.queues[key] = q[1:]
+		d.mu.Unlock()
+
+		fn()
+	}
+}
+
+// Close waits for all queues to drain.
+func (d *KeyedDispatcher) Close() {
+	d.wg.Wait()
+}
@@ -0,0 +1,213 @@
+package maelstrom_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Ensure the pool dispatcher limits concurrency & rejects messages once full.
+func TestPoolDispatcher(t *testing.T) {
+	d := maelstrom.NewPoolDispatcher(2, 1)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	for i := 0; i < 3; i++ {
+		if err := d.Dispatch(maelstrom.Message{}, func() {
+			started <- struct{}{}
+			<-release
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two workers are busy & one message is queued.
+	<-started
+	<-started
+	if err := d.Dispatch(maelstrom.Message{}, func() {}); err != maelstrom.ErrQueueFull {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-started:
+		t.Fatal("expected third message to wait for a worker")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	d.Close()
+	if got, want := len(started), 1; got != want {
+		t.Fatalf("started=%d, want %d", got, want)
+	}
+}
+
+// Ensure the actor dispatcher executes messages in order, one at a time.
+func TestActorDispatcher(t *testing.T) {
+	d := maelstrom.NewActorDispatcher(100)
+
+	var got []int
+	for i := 0; i < 100; i++ {
+		i := i
+		if err := d.Dispatch(maelstrom.Message{}, func() { got = append(got, i) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Close()
+
+	for i := range got {
+		if got[i] != i {
+			t.Fatalf("unexpected order: %v", got)
+		}
+	}
+	if len(got) != 100 {
+		t.Fatalf("len=%d, want 100", len(got))
+	}
+}
+
+// Ensure the keyed dispatcher orders messages per key but runs keys concurrently.
+func TestKeyedDispatcher(t *testing.T) {
+	d := maelstrom.NewKeyedDispatcher(func(msg maelstrom.Message) string { return msg.Src }, 0)
+
+	// Block key "a" and ensure key "b" still makes progress.
+	release := make(chan struct{})
+	if err := d.Dispatch(maelstrom.Message{Src: "a"}, func() { <-release }); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	got := make(map[string][]int)
+	for i := 0; i < 10; i++ {
+		for _, key := range []string{"a", "b"} {
+			key, i := key, i
+			if err := d.Dispatch(maelstrom.Message{Src: key}, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				got[key] = append(got[key], i)
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	waitUntil(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got["b"]) == 10
+	})
+	mu.Lock()
+	if n := len(got["a"]); n != 0 {
+		t.Fatalf("expected key a to be blocked, got %d", n)
+	}
+	mu.Unlock()
+
+	close(release)
+	d.Close()
+
+	want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	if !reflect.DeepEqual(got["a"], want) || !reflect.DeepEqual(got["b"], want) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
+
+// Ensure a key's queue size limit is enforced.
+func TestKeyedDispatcher_ErrQueueFull(t *testing.T) {
+	d := maelstrom.NewKeyedDispatcher(func(msg maelstrom.Message) string { return msg.Src }, 1)
+	defer d.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	if err := d.Dispatch(maelstrom.Message{Src: "a"}, func() { close(started); <-release }); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if err := d.Dispatch(maelstrom.Message{Src: "a"}, func() {}); err != nil {
+		t.Fatal(err)
+	} else if err := d.Dispatch(maelstrom.Message{Src: "a"}, func() {}); err != maelstrom.ErrQueueFull {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := d.Dispatch(maelstrom.Message{Src: "b"}, func() {}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure an actor node can make synchronous RPCs from its handler and rejects
+// requests which overflow its queue.
+func TestNode_Dispatcher_Actor(t *testing.T) {
+	n, stdin, stdout := newNode(t)
+	n.Dispatcher = maelstrom.NewActorDispatcher(1)
+
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		ctx, cancel := context.WithTimeout(msg.Context(), 5*time.Second)
+		defer cancel()
+		if _, err := n.SyncRPC(ctx, "n2", map[string]any{"type": "bar"}); err != nil {
+			return err
+		}
+		return n.Reply(msg, map[string]any{"type": "foo_ok"})
+	})
+	initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+	readLine := func() string {
+		t.Helper()
+		line, err := stdout.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return line
+	}
+	write := func(s string) {
+		t.Helper()
+		if _, err := stdin.Write([]byte(s + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first request blocks the worker on an RPC.
+	write(`{"src":"c1","dest":"n1","body":{"type":"foo","msg_id":2}}`)
+	if got, want := readLine(), `{"src":"n1","dest":"n2","body":{"msg_id":1,"type":"bar"}}`+"\n"; got != want {
+		t.Fatalf("request=%s, want %s", got, want)
+	}
+
+	// The second request is queued and the third is rejected.
+	write(`{"src":"c1","dest":"n1","body":{"type":"foo","msg_id":3}}`)
+	write(`{"src":"c1","dest":"n1","body":{"type":"foo","msg_id":4}}`)
+	if got, want := readLine(), `{"src":"n1","dest":"c1","body":{"code":11,"in_reply_to":4,"text":"dispatch queue full","type":"error"}}`+"\n"; got != want {
+		t.Fatalf("response=%s, want %s", got, want)
+	}
+
+	// A rejected message without a msg_id is dropped without a reply.
+	write(`{"src":"n2","dest":"n1","body":{"type":"foo"}}`)
+
+	// The response callback is not blocked by the busy worker.
+	write(`{"src":"n2","dest":"n1","body":{"type":"bar_ok","in_reply_to":1}}`)
+	if got, want := readLine(), `{"src":"n1","dest":"c1","body":{"in_reply_to":2,"type":"foo_ok"}}`+"\n"; got != want {
+		t.Fatalf("response=%s, want %s", got, want)
+	}
+
+	// The queued request then executes.
+	if got, want := readLine(), `{"src":"n1","dest":"n2","body":{"msg_id":2,"type":"bar"}}`+"\n"; got != want {
+		t.Fatalf("request=%s, want %s", got, want)
+	}
+	write(`{"src":"n2","dest":"n1","body":{"type":"bar_ok","in_reply_to":2}}`)
+	if got, want := readLine(), `{"src":"n1","dest":"c1","body":{"in_reply_to":3,"type":"foo_ok"}}`+"\n"; got != want {
+		t.Fatalf("response=%s, want %s", got, want)
+	}
+}
+
+// waitUntil polls fn until it returns true or fails the test after a timeout.
+func waitUntil(tb testing.TB, fn func() bool) {
+	tb.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for !fn() {
+		select {
+		case <-timeout:
+			tb.Fatal("timeout")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package maelstrom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotAwaited is the error of a FanOut destination which had not responded
+// when the quorum was reached. Its response, if any, is dropped.
+var ErrNotAwaited = errors.New("response not awaited")
+
+// Quorum is the number of successful responses required by FanOut. Positive
+// values require that many responses.
+type Quorum int
+
+const (
+	QuorumAll      Quorum = -1 // every destination
+	QuorumMajority Quorum = -2 // more than half of the destinations
+	QuorumFirst    Quorum = 1  // any one destination
+)
+
+// Required returns the number of successful responses required from n
+// destinations.
+func (q Quorum) Required(n int) int {
+	switch q {
+	case QuorumAll:
+		return n
+	case QuorumMajority:
+		return n/2 + 1
+	default:
+		return int(q)
+	}
+}
+
+// String returns "all", "majority" or the required number of responses.
+func (q Quorum) String() string {
+	switch q {
+	case QuorumAll:
+		return "all"
+	case QuorumMajority:
+		return "majority"
+	default:
+		return fmt.Sprint(int(q))
+	}
+}
+
+// QuorumError is returned by FanOut when too few destinations responded
+// successfully.
+type QuorumError struct {
+	Required  int
+	Succeeded int
+
+	// Context error, if the context was done before the quorum was reached.
+	Err error
+}
+
+// Error returns a description of the error.
+func (e *QuorumError) Error() string {
+	s := fmt.Sprintf("quorum not reached: %d of %d required responses succeeded", e.Succeeded, e.Required)
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the context error, if any.
+func (e *QuorumError) Unwrap() error {
+	return e.Err
+}
+
+// FanOutResult represents the outcome of a FanOut request to one destination.
+type FanOutResult struct {
+	Dest string
+
+	// Response message, if one was received.
+	Msg Message
+
+	// RPC error from the response, the error from sending the request, a
+	// Timeout error if the context expired or ErrNotAwaited.
+	Err error
+}
+
+// FanOut sends body as an RPC request to every destination and waits until
+// the quorum of successful responses is received. A response is successful
+// if it is not an RPC error.
+//
+// Results are returned in the order of dests, even on error. Returns a
+// *QuorumError if the context is done before the quorum is reached, or as
+// soon as too many requests have failed for it to be reached. Callbacks for
+// outstanding requests are deregistered on return.
+func (n *Node) FanOut(ctx context.Context, dests []string, body any, q Quorum) ([]FanOutResult, error) {
+	required := q.Required(len(dests))
+
+	// Encode once for all destinations.
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	type response struct {
+		i   int
+		msg Message
+	}
+	ch := make(chan response, len(dests))
+
+	results := make([]FanOutResult, len(dests))
+	msgIDs := make([]int, len(dests)) // outstanding requests, zero if done
+	var succeeded, failed int
+	for i, dest := range dests {
+		i := i
+		results[i] = FanOutResult{Dest: dest, Err: ErrNotAwaited}
+
+		msgID, err := n.rpc(dest, json.RawMessage(buf), func(m Message) error {
+			ch <- response{i: i, msg: m}
+			return nil
+		})
+		if err != nil {
+			results[i].Err = err
+			failed++
+			continue
+		}
+		msgIDs[i] = msgID
+	}
+
+	defer func() {
+		for _, msgID := range msgIDs {
+			if msgID != 0 {
+				n.removeCallback(msgID)
+			}
+		}
+	}()
+
+	for succeeded < required && len(dests)-failed >= required {
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			for i, msgID := range msgIDs {
+				if msgID == 0 {
+					continue
+				} else if errors.Is(err, context.DeadlineExceeded) {
+					results[i].Err = newTimeoutError(err)
+				} else {
+					results[i].Err = err
+				}
+			}
+			return results, &QuorumError{Required: required, Succeeded: succeeded, Err: err}
+
+		case resp := <-ch:
+			msgIDs[resp.i] = 0
+			results[resp.i].Msg, results[resp.i].Err = resp.msg, nil
+			if rpcErr := resp.msg.RPCError(); rpcErr != nil {
+				results[resp.i].Err = rpcErr
+				failed++
+			} else {
+				succeeded++
+			}
+		}
+	}
+
+	if succeeded < required {
+		return results, &QuorumError{Required: required, Succeeded: succeeded}
+	}
+	return results, nil
+}
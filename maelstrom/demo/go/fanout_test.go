@@ -0,0 +1,142 @@
+package maelstrom_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestNode_FanOut(t *testing.T) {
+	type fanOut struct {
+		results []maelstrom.FanOutResult
+		err     error
+	}
+
+	// start initializes a node and fans out a request to n2, n3 & n4. The
+	// requests are read from the network before returning.
+	start := func(t *testing.T, ctx context.Context, q maelstrom.Quorum) (reply func(string), ch chan fanOut) {
+		n, stdin, stdout := newNode(t)
+		initNode(t, n, "n1", []string{"n1", "n2", "n3", "n4"}, stdin, stdout)
+
+		ch = make(chan fanOut, 1)
+		go func() {
+			results, err := n.FanOut(ctx, []string{"n2", "n3", "n4"}, maelstrom.MessageBody{Type: "foo"}, q)
+			ch <- fanOut{results, err}
+		}()
+
+		for i := 0; i < 3; i++ {
+			if _, err := stdout.ReadString('\n'); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Destinations n2, n3 & n4 are sent msg_id 1, 2 & 3 respectively.
+		return func(line string) {
+			if _, err := stdin.Write([]byte(line + "\n")); err != nil {
+				t.Fatal(err)
+			}
+		}, ch
+	}
+
+	wait := func(t *testing.T, ch chan fanOut) fanOut {
+		select {
+		case v := <-ch:
+			return v
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for fan out")
+			return fanOut{}
+		}
+	}
+
+	t.Run("Majority", func(t *testing.T) {
+		reply, ch := start(t, context.Background(), maelstrom.QuorumMajority)
+		reply(`{"src":"n4", "dest":"n1", "body":{"type":"foo_ok", "in_reply_to":3}}`)
+		reply(`{"src":"n2", "dest":"n1", "body":{"type":"foo_ok", "in_reply_to":1}}`)
+
+		v := wait(t, ch)
+		if v.err != nil {
+			t.Fatal(v.err)
+		} else if got, want := v.results[0].Msg.Type(), "foo_ok"; got != want {
+			t.Fatalf("type=%s, want %s", got, want)
+		} else if got, want := v.results[1].Err, maelstrom.ErrNotAwaited; got != want {
+			t.Fatalf("err=%v, want %v", got, want)
+		} else if got, want := v.results[2].Dest, "n4"; got != want {
+			t.Fatalf("dest=%s, want %s", got, want)
+		} else if v.results[2].Err != nil {
+			t.Fatal(v.results[2].Err)
+		}
+	})
+
+	t.Run("First", func(t *testing.T) {
+		reply, ch := start(t, context.Background(), maelstrom.QuorumFirst)
+		reply(`{"src":"n3", "dest":"n1", "body":{"type":"foo_ok", "in_reply_to":2}}`)
+
+		v := wait(t, ch)
+		if v.err != nil {
+			t.Fatal(v.err)
+		} else if got, want := v.results[0].Err, maelstrom.ErrNotAwaited; got != want {
+			t.Fatalf("err=%v, want %v", got, want)
+		} else if v.results[1].Err != nil {
+			t.Fatal(v.results[1].Err)
+		}
+	})
+
+	// Ensure fan out returns once the quorum can no longer be reached.
+	t.Run("ErrAll", func(t *testing.T) {
+		reply, ch := start(t, context.Background(), maelstrom.QuorumAll)
+		reply(`{"src":"n3", "dest":"n1", "body":{"type":"error", "code":13, "text":"boom", "in_reply_to":2}}`)
+
+		v := wait(t, ch)
+		var qerr *maelstrom.QuorumError
+		if !errors.As(v.err, &qerr) {
+			t.Fatalf("unexpected error: %v", v.err)
+		} else if got, want := v.err.Error(), `quorum not reached: 0 of 3 required responses succeeded`; got != want {
+			t.Fatalf("err=%s, want %s", got, want)
+		} else if got, want := maelstrom.ErrorCode(v.results[1].Err), maelstrom.Crash; got != want {
+			t.Fatalf("code=%d, want %d", got, want)
+		} else if got, want := v.results[2].Err, maelstrom.ErrNotAwaited; got != want {
+			t.Fatalf("err=%v, want %v", got, want)
+		}
+	})
+
+	t.Run("ErrContextTimeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		reply, ch := start(t, ctx, maelstrom.Quorum(2))
+		reply(`{"src":"n2", "dest":"n1", "body":{"type":"foo_ok", "in_reply_to":1}}`)
+
+		v := wait(t, ch)
+		if v.err == nil || v.err.Error() != `quorum not reached: 1 of 2 required responses succeeded: context deadline exceeded` {
+			t.Fatalf("unexpected error: %v", v.err)
+		} else if !errors.Is(v.err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded error: %#v", v.err)
+		} else if got, want := maelstrom.ErrorCode(v.results[1].Err), maelstrom.Timeout; got != want {
+			t.Fatalf("code=%d, want %d", got, want)
+		}
+
+		// Late responses are dropped.
+		reply(`{"src":"n3", "dest":"n1", "body":{"type":"foo_ok", "in_reply_to":2}}`)
+	})
+}
+
+func TestQuorum_Required(t *testing.T) {
+	for _, tt := range []struct {
+		q    maelstrom.Quorum
+		n    int
+		want int
+	}{
+		{maelstrom.QuorumAll, 5, 5},
+		{maelstrom.QuorumMajority, 5, 3},
+		{maelstrom.QuorumMajority, 4, 3},
+		{maelstrom.QuorumFirst, 5, 1},
+		{maelstrom.Quorum(2), 5, 2},
+	} {
+		if got := tt.q.Required(tt.n); got != tt.want {
+			t.Errorf("%s of %d=%d, want %d", tt.q, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package hlc implements hybrid logical clocks. A hybrid logical clock
+// produces timestamps which stay close to physical time but are monotonic
+// and consistent with causality, even when the physical clocks of nodes are
+// skewed. This makes them suitable for last-writer-wins conflict resolution
+// & snapshot timestamps.
+//
+// A Clock implements maelstrom.LogicalClock so it can be attached to a node,
+// which then stamps messages to peers & updates the clock on every message
+// received from them:
+//
+//	n := maelstrom.NewNode()
+//	clock := hlc.New(n.Clock)
+//	n.LogicalClock = clock
+package hlc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Timestamp represents a hybrid logical clock timestamp. Timestamps are
+// ordered by wall time and then by logical counter.
+type Timestamp struct {
+	WallTime int64  `json:"wall"`    // physical time, in nanoseconds since the Unix epoch
+	Logical  uint32 `json:"logical"` // counter for events with the same wall time
+}
+
+// Compare returns -1 if t is less than other, 1 if t is greater than other
+// and 0 if they are equal.
+func (t Timestamp) Compare(other Timestamp) int {
+	switch {
+	case t.WallTime < other.WallTime:
+		return -1
+	case t.WallTime > other.WallTime:
+		return 1
+	case t.Logical < other.Logical:
+		return -1
+	case t.Logical > other.Logical:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Less returns true if t is less than other.
+func (t Timestamp) Less(other Timestamp) bool {
+	return t.Compare(other) < 0
+}
+
+// IsZero returns true if t is the zero timestamp.
+func (t Timestamp) IsZero() bool {
+	return t == Timestamp{}
+}
+
+// Time returns the wall time of t.
+func (t Timestamp) Time() time.Time {
+	return time.Unix(0, t.WallTime)
+}
+
+// String returns the timestamp as "<wall>.<logical>".
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%d.%d", t.WallTime, t.Logical)
+}
+
+// Clock is a hybrid logical clock. It is safe for concurrent use.
+type Clock struct {
+	mu       sync.Mutex
+	physical maelstrom.Clock
+	last     Timestamp
+
+	// MaxOffset is the maximum distance a received timestamp may be ahead of
+	// the local physical clock. Timestamps further ahead are rejected by
+	// Update so that a node with a runaway clock cannot drag the cluster
+	// forward. Zero means no limit.
+	MaxOffset time.Duration
+}
+
+// New returns a new clock driven by the given physical clock. A nil physical
+// clock uses the system time.
+func New(physical maelstrom.Clock) *Clock {
+	if physical == nil {
+		physical = maelstrom.SystemClock{}
+	}
+	return &Clock{physical: physical}
+}
+
+// Now returns a timestamp for a local or send event. The timestamp is
+// greater than every timestamp previously returned or received.
+func (c *Clock) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pt := c.physical.Now().UnixNano(); pt > c.last.WallTime {
+		c.last = Timestamp{WallTime: pt}
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Last returns the most recent timestamp issued or received, without
+// advancing the clock.
+func (c *Clock) Last() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// Update advances the clock past a timestamp received from another node and
+// returns the timestamp of the receive event. Returns an error, without
+// updating the clock, if ts exceeds the physical clock by more than
+// MaxOffset.
+func (c *Clock) Update(ts Timestamp) (Timestamp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pt := c.physical.Now().UnixNano()
+	if c.MaxOffset > 0 && ts.WallTime-pt > int64(c.MaxOffset) {
+		return Timestamp{}, fmt.Errorf("timestamp %s exceeds max offset of %s by %s", ts, c.MaxOffset, time.Duration(ts.WallTime-pt)-c.MaxOffset)
+	}
+
+	last := c.last
+	wall := pt
+	if last.WallTime > wall {
+		wall = last.WallTime
+	}
+	if ts.WallTime > wall {
+		wall = ts.WallTime
+	}
+
+	next := Timestamp{WallTime: wall}
+	switch {
+	case wall == last.WallTime && wall == ts.WallTime:
+		next.Logical = last.Logical
+		if ts.Logical > next.Logical {
+			next.Logical = ts.Logical
+		}
+		next.Logical++
+	case wall == last.WallTime:
+		next.Logical = last.Logical + 1
+	case wall == ts.WallTime:
+		next.Logical = ts.Logical + 1
+	}
+
+	c.last = next
+	return next, nil
+}
+
+// Field returns "hlc", the name of the body field which carries timestamps.
+func (c *Clock) Field() string { return "hlc" }
+
+// Send returns the encoded timestamp of a send event.
+func (c *Clock) Send() (json.RawMessage, error) {
+	return json.Marshal(c.Now())
+}
+
+// Receive updates the clock with an encoded timestamp from a received
+// message.
+func (c *Clock) Receive(ts json.RawMessage) error {
+	var t Timestamp
+	if err := json.Unmarshal(ts, &t); err != nil {
+		return fmt.Errorf("unmarshal hlc timestamp: %w", err)
+	}
+	_, err := c.Update(t)
+	return err
+}
+
+// Timestamp returns the timestamp carried by msg. Returns the zero
+// timestamp if the message has none.
+func (c *Clock) Timestamp(msg maelstrom.Message) (Timestamp, error) {
+	var body struct {
+		T Timestamp `json:"hlc"`
+	}
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return Timestamp{}, err
+	}
+	return body.T, nil
+}
@@ -0,0 +1,144 @@
+package hlc_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/hlc"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+)
+
+func TestClock_Now(t *testing.T) {
+	physical := maelstrom.NewManualClock(time.Unix(100, 0))
+	c := hlc.New(physical)
+
+	if got, want := c.Now(), (hlc.Timestamp{WallTime: 100e9}); got != want {
+		t.Fatalf("now=%s, want %s", got, want)
+	}
+
+	// Ensure timestamps advance while the physical clock stalls or goes back.
+	if got, want := c.Now(), (hlc.Timestamp{WallTime: 100e9, Logical: 1}); got != want {
+		t.Fatalf("now=%s, want %s", got, want)
+	}
+	physical.Advance(-time.Second)
+	if got, want := c.Now(), (hlc.Timestamp{WallTime: 100e9, Logical: 2}); got != want {
+		t.Fatalf("now=%s, want %s", got, want)
+	}
+
+	// Ensure the logical counter resets once the physical clock catches up.
+	physical.Advance(2 * time.Second)
+	if got, want := c.Now(), (hlc.Timestamp{WallTime: 101e9}); got != want {
+		t.Fatalf("now=%s, want %s", got, want)
+	}
+}
+
+func TestClock_Update(t *testing.T) {
+	t.Run("Skew", func(t *testing.T) {
+		// The local physical clock is 50s behind the remote clock.
+		physical := maelstrom.NewManualClock(time.Unix(50, 0))
+		c := hlc.New(physical)
+
+		remote := hlc.Timestamp{WallTime: 100e9, Logical: 3}
+		if ts, err := c.Update(remote); err != nil {
+			t.Fatal(err)
+		} else if got, want := ts, (hlc.Timestamp{WallTime: 100e9, Logical: 4}); got != want {
+			t.Fatalf("ts=%s, want %s", got, want)
+		} else if !remote.Less(ts) {
+			t.Fatalf("expected %s < %s", remote, ts)
+		}
+
+		// Later events follow the received timestamp.
+		if got, want := c.Now(), (hlc.Timestamp{WallTime: 100e9, Logical: 5}); got != want {
+			t.Fatalf("now=%s, want %s", got, want)
+		}
+
+		// Older timestamps only advance the logical counter.
+		if ts, err := c.Update(hlc.Timestamp{WallTime: 10e9}); err != nil {
+			t.Fatal(err)
+		} else if got, want := ts, (hlc.Timestamp{WallTime: 100e9, Logical: 6}); got != want {
+			t.Fatalf("ts=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("Physical", func(t *testing.T) {
+		c := hlc.New(maelstrom.NewManualClock(time.Unix(200, 0)))
+		if ts, err := c.Update(hlc.Timestamp{WallTime: 100e9, Logical: 7}); err != nil {
+			t.Fatal(err)
+		} else if got, want := ts, (hlc.Timestamp{WallTime: 200e9}); got != want {
+			t.Fatalf("ts=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("ErrMaxOffset", func(t *testing.T) {
+		c := hlc.New(maelstrom.NewManualClock(time.Unix(100, 0)))
+		c.MaxOffset = time.Second
+		if _, err := c.Update(hlc.Timestamp{WallTime: 102e9}); err == nil || err.Error() != `timestamp 102000000000.0 exceeds max offset of 1s by 1s` {
+			t.Fatalf("unexpected error: %v", err)
+		} else if !c.Last().IsZero() {
+			t.Fatalf("unexpected update: %s", c.Last())
+		}
+	})
+}
+
+func TestTimestamp_JSON(t *testing.T) {
+	buf, err := json.Marshal(hlc.Timestamp{WallTime: 5, Logical: 2})
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), `{"wall":5,"logical":2}`; got != want {
+		t.Fatalf("json=%s, want %s", got, want)
+	}
+}
+
+// Ensure timestamps flow between nodes with skewed clocks.
+func TestClock_Node(t *testing.T) {
+	net := simnet.NewNetwork()
+
+	// Node n1's physical clock is an hour ahead of n2's.
+	n1, n2 := maelstrom.NewNode(), maelstrom.NewNode()
+	c1 := hlc.New(maelstrom.NewManualClock(time.Unix(3600, 0)))
+	c2 := hlc.New(maelstrom.NewManualClock(time.Unix(0, 0)))
+	n1.LogicalClock, n2.LogicalClock = c1, c2
+
+	n1.Handle("ping", func(msg maelstrom.Message) error {
+		if _, err := n1.SyncRPC(msg.Context(), "n2", maelstrom.MessageBody{Type: "pong"}); err != nil {
+			return err
+		}
+		return n1.Reply(msg, maelstrom.MessageBody{Type: "ping_ok"})
+	})
+
+	received := make(chan hlc.Timestamp, 1)
+	n2.Handle("pong", func(msg maelstrom.Message) error {
+		ts, err := c2.Timestamp(msg)
+		if err != nil {
+			return err
+		}
+		received <- ts
+		return n2.Reply(msg, maelstrom.MessageBody{Type: "pong_ok"})
+	})
+
+	net.AddNode("n1", n1)
+	net.AddNode("n2", n2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer net.Close()
+
+	if _, err := net.Client().SyncRPC(ctx, "n1", maelstrom.MessageBody{Type: "ping"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := <-received
+	if got, want := sent, (hlc.Timestamp{WallTime: 3600e9}); got != want {
+		t.Fatalf("sent=%s, want %s", got, want)
+	} else if last := c2.Last(); !sent.Less(last) {
+		t.Fatalf("expected %s < %s", sent, last)
+	} else if last := c1.Last(); last.Compare(c2.Last()) <= 0 {
+		t.Fatalf("expected reply to advance n1 past %s, got %s", c2.Last(), last)
+	}
+}
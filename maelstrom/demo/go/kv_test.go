@@ -33,7 +33,7 @@ func TestKVReadStruct(t *testing.T) {
 		// Ensure RPC request is received by the network.
 		if line, err := stdout.ReadString('\n'); err != nil {
 			t.Fatal(err)
-		} else if got, want := line, `{"src":"n1","dest":"seq-kv","body":{"key":"foo","msg_id":1,"type":"read"}}`+"\n"; got != want {
+		} else if got, want := line, `{"src":"n1","dest":"seq-kv","body":{"msg_id":1,"type":"read","key":"foo"}}`+"\n"; got != want {
 			t.Fatalf("response=%s, want %s", got, want)
 		}
 
@@ -70,7 +70,7 @@ func TestKVReadStruct(t *testing.T) {
 		// Ensure RPC request is received by the network.
 		if line, err := stdout.ReadString('\n'); err != nil {
 			t.Fatal(err)
-		} else if got, want := line, `{"src":"n1","dest":"seq-kv","body":{"key":"foo","msg_id":1,"type":"read"}}`+"\n"; got != want {
+		} else if got, want := line, `{"src":"n1","dest":"seq-kv","body":{"msg_id":1,"type":"read","key":"foo"}}`+"\n"; got != want {
 			t.Fatalf("response=%s, want %s", got, want)
 		}
 
@@ -0,0 +1,41 @@
+package lease
+
+import (
+	"fmt"
+	"sync"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Fence rejects requests made under a lease which has been superseded. Lease
+// holders attach their epoch to the requests they send, such as writes
+// forwarded to the owner of a key, and receivers check it with Check.
+type Fence struct {
+	mu     sync.Mutex
+	epochs map[string]uint64 // highest epoch seen, by resource
+}
+
+// NewFence returns a new instance of Fence.
+func NewFence() *Fence {
+	return &Fence{epochs: make(map[string]uint64)}
+}
+
+// Epoch returns the highest epoch seen for resource.
+func (f *Fence) Epoch(resource string) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.epochs[resource]
+}
+
+// Check records epoch for resource. Returns an *RPCError with a
+// PreconditionFailed code if a higher epoch has already been seen.
+func (f *Fence) Check(resource string, epoch uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if cur := f.epochs[resource]; epoch < cur {
+		return maelstrom.NewRPCError(maelstrom.PreconditionFailed, fmt.Sprintf("stale epoch %d for %q, current epoch is %d", epoch, resource, cur))
+	}
+	f.epochs[resource] = epoch
+	return nil
+}
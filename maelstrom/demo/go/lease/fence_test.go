@@ -0,0 +1,29 @@
+package lease_test
+
+import (
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/lease"
+)
+
+func TestFence_Check(t *testing.T) {
+	f := lease.NewFence()
+	if err := f.Check("foo", 2); err != nil {
+		t.Fatal(err)
+	} else if err := f.Check("foo", 2); err != nil {
+		t.Fatal(err)
+	} else if err := f.Check("foo", 1); maelstrom.ErrorCode(err) != maelstrom.PreconditionFailed {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := f.Check("bar", 1); err != nil {
+		t.Fatal(err)
+	} else if err := f.Check("foo", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := f.Epoch("foo"), uint64(3); got != want {
+		t.Fatalf("epoch=%d, want %d", got, want)
+	} else if got, want := f.Epoch("baz"), uint64(0); got != want {
+		t.Fatalf("epoch=%d, want %d", got, want)
+	}
+}
@@ -0,0 +1,388 @@
+// Package lease implements time-bounded leases over a linearizable key/value
+// store. A lease grants a single node ownership of a resource, such as a key
+// or a partition, until it expires. Holders renew their leases in the
+// background and are notified when a lease is lost.
+//
+// Each acquisition of a resource increments its epoch. The epoch serves as a
+// fencing token: a node which has lost its lease, but does not know it yet,
+// still sends its old epoch, which a Fence rejects once a newer one has been
+// seen.
+//
+// Expiry is judged by comparing the node clocks to the expiry time stored in
+// the key/value store, so leases are only safe when node clocks are
+// synchronized, as they are under Maelstrom.
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+var (
+	// ErrLost is returned by operations on a lease which expired before it
+	// could be renewed or which was acquired by another node.
+	ErrLost = errors.New("lease lost")
+
+	// ErrReleased is returned by operations on a released lease.
+	ErrReleased = errors.New("lease released")
+)
+
+// HeldError is returned when a resource is leased by another node.
+type HeldError struct {
+	Resource string
+	Holder   string
+	Epoch    uint64
+	Expires  time.Time
+}
+
+// Error implements the error interface.
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("lease on %q held by %s until %s", e.Resource, e.Holder, e.Expires.Format(time.RFC3339Nano))
+}
+
+// Config holds the parameters of a Manager.
+type Config struct {
+	// Length of each lease, measured from the time the acquire or renew
+	// request is sent.
+	Duration time.Duration
+
+	// Interval between renewals of held leases. Must be shorter than
+	// Duration so that renewals can be retried before the lease expires.
+	RenewInterval time.Duration
+
+	// Interval between attempts by Acquire while a resource is held.
+	RetryInterval time.Duration
+
+	// Prefix of the keys which store leases, followed by the resource name.
+	Prefix string
+}
+
+// DefaultConfig returns two second leases renewed every 500ms.
+func DefaultConfig() Config {
+	return Config{
+		Duration:      2 * time.Second,
+		RenewInterval: 500 * time.Millisecond,
+		RetryInterval: 100 * time.Millisecond,
+		Prefix:        "lease/",
+	}
+}
+
+// withDefaults returns c with zero fields set from DefaultConfig.
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Duration <= 0 {
+		c.Duration = def.Duration
+	}
+	if c.RenewInterval <= 0 {
+		c.RenewInterval = c.Duration / 4
+	}
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = def.RetryInterval
+	}
+	if c.Prefix == "" {
+		c.Prefix = def.Prefix
+	}
+	return c
+}
+
+// record is the state of a lease as stored in the key/value store. A blank
+// holder means the resource is free.
+type record struct {
+	Holder  string `json:"holder"`
+	Epoch   uint64 `json:"epoch"`
+	Expires int64  `json:"expires"` // unix nanoseconds
+}
+
+func (r record) expires() time.Time {
+	return time.Unix(0, r.Expires)
+}
+
+// Manager acquires & renews leases for a node.
+type Manager struct {
+	node *maelstrom.Node
+	kv   *maelstrom.KV
+	cfg  Config
+
+	mu     sync.Mutex
+	leases map[string]*Lease // held leases, by resource
+}
+
+// NewManager returns a manager which stores leases in kv, which must be
+// linearizable. Zero fields in cfg are set from DefaultConfig; RenewInterval
+// defaults to a quarter of Duration.
+func NewManager(n *maelstrom.Node, kv *maelstrom.KV, cfg Config) *Manager {
+	return &Manager{
+		node:   n,
+		kv:     kv,
+		cfg:    cfg.withDefaults(),
+		leases: make(map[string]*Lease),
+	}
+}
+
+// Holder returns the node currently holding the lease on resource & its
+// epoch. Returns a blank holder if the resource is free or its lease has
+// expired.
+func (m *Manager) Holder(ctx context.Context, resource string) (holder string, epoch uint64, err error) {
+	rec, err := m.read(ctx, resource)
+	if err != nil {
+		return "", 0, err
+	} else if !m.node.Clock.Now().Before(rec.expires()) {
+		return "", rec.Epoch, nil
+	}
+	return rec.Holder, rec.Epoch, nil
+}
+
+// TryAcquire attempts to acquire the lease on resource once. Returns a
+// *HeldError if another node holds an unexpired lease. Returns the existing
+// lease if this node already holds it.
+//
+// The lease is renewed in the background until it is released or lost, so
+// TryAcquire must be called before the node's Run() returns.
+func (m *Manager) TryAcquire(ctx context.Context, resource string) (*Lease, error) {
+	m.mu.Lock()
+	l := m.leases[resource]
+	m.mu.Unlock()
+	if l != nil && l.Valid() {
+		return l, nil
+	} else if l != nil {
+		l.lose(ErrLost)
+	}
+
+	start := m.node.Clock.Now()
+	cur, err := m.read(ctx, resource)
+	if err != nil {
+		return nil, err
+	} else if cur.Holder != "" && start.Before(cur.expires()) && cur.Holder != m.node.ID() {
+		return nil, &HeldError{Resource: resource, Holder: cur.Holder, Epoch: cur.Epoch, Expires: cur.expires()}
+	}
+
+	// Take over the lease with a new epoch. If the record does not exist yet
+	// then it is only created if no other node creates it first.
+	next := record{Holder: m.node.ID(), Epoch: cur.Epoch + 1, Expires: start.Add(m.cfg.Duration).UnixNano()}
+	var from any = cur
+	if cur == (record{}) {
+		from = nil
+	}
+	if err := m.kv.CompareAndSwap(ctx, m.key(resource), from, next, true); err != nil {
+		return nil, err
+	}
+
+	l = &Lease{
+		m:        m,
+		resource: resource,
+		record:   next,
+		lost:     make(chan struct{}),
+	}
+
+	// Start renewing before the lease is published, as lose() stops the task.
+	l.mu.Lock()
+	l.task = m.node.Every(m.cfg.RenewInterval, 0, l.renew)
+	l.mu.Unlock()
+
+	m.mu.Lock()
+	m.leases[resource] = l
+	m.mu.Unlock()
+	return l, nil
+}
+
+// Acquire waits until the lease on resource is acquired, retrying every
+// RetryInterval while it is held by another node or contended. Returns an
+// error if ctx is done first.
+func (m *Manager) Acquire(ctx context.Context, resource string) (*Lease, error) {
+	for {
+		l, err := m.TryAcquire(ctx, resource)
+		if err == nil {
+			return l, nil
+		}
+
+		var held *HeldError
+		if !errors.As(err, &held) && maelstrom.ErrorCode(err) != maelstrom.PreconditionFailed {
+			return nil, err
+		}
+
+		timer := m.node.Clock.NewTimer(m.cfg.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// read returns the stored lease for resource, or an empty record if the
+// resource has never been leased.
+func (m *Manager) read(ctx context.Context, resource string) (record, error) {
+	var rec record
+	if err := m.kv.ReadInto(ctx, m.key(resource), &rec); maelstrom.ErrorCode(err) == maelstrom.KeyDoesNotExist {
+		return record{}, nil
+	} else if err != nil {
+		return record{}, err
+	}
+	return rec, nil
+}
+
+func (m *Manager) key(resource string) string {
+	return m.cfg.Prefix + resource
+}
+
+// remove stops tracking l once it is no longer held.
+func (m *Manager) remove(l *Lease) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases[l.resource] == l {
+		delete(m.leases, l.resource)
+	}
+}
+
+// Lease represents ownership of a resource by this node.
+type Lease struct {
+	m        *Manager
+	resource string
+
+	renewMu sync.Mutex // serializes renewals & release
+
+	mu     sync.Mutex
+	record record
+	task   *maelstrom.Task
+	lost   chan struct{}
+	err    error
+}
+
+// Resource returns the name of the leased resource.
+func (l *Lease) Resource() string { return l.resource }
+
+// Epoch returns the fencing token of the lease. Every acquisition of the
+// resource has a higher epoch than the previous one.
+func (l *Lease) Epoch() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.record.Epoch
+}
+
+// Expires returns the time the lease expires unless it is renewed.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.record.expires()
+}
+
+// Valid returns true if the lease has not been lost, released or expired.
+func (l *Lease) Valid() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err == nil && l.m.node.Clock.Now().Before(l.record.expires())
+}
+
+// Lost returns a channel which is closed once the lease is lost or released.
+func (l *Lease) Lost() <-chan struct{} { return l.lost }
+
+// Err returns ErrLost or ErrReleased once the lease is no longer held.
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Renew extends the lease by the configured Duration. Returns ErrLost if the
+// lease expired or was acquired by another node. Other errors, such as
+// timeouts, leave the lease valid until it expires.
+func (l *Lease) Renew(ctx context.Context) error {
+	l.renewMu.Lock()
+	defer l.renewMu.Unlock()
+
+	l.mu.Lock()
+	cur, err := l.record, l.err
+	l.mu.Unlock()
+
+	start := l.m.node.Clock.Now()
+	if err != nil {
+		return err
+	} else if !start.Before(cur.expires()) {
+		return l.lose(ErrLost)
+	}
+
+	next := cur
+	next.Expires = start.Add(l.m.cfg.Duration).UnixNano()
+	if err := l.m.kv.CompareAndSwap(ctx, l.m.key(l.resource), cur, next, false); err != nil {
+		switch maelstrom.ErrorCode(err) {
+		case maelstrom.PreconditionFailed, maelstrom.KeyDoesNotExist:
+			// A previous renewal may have been applied without us learning
+			// of it, so check whether we are still the holder.
+			if next, err = l.m.read(ctx, l.resource); err != nil {
+				return err
+			} else if next.Holder != cur.Holder || next.Epoch != cur.Epoch {
+				return l.lose(ErrLost)
+			}
+		default:
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.record = next
+	}
+	return l.err
+}
+
+// Release gives up the lease so that another node can acquire it without
+// waiting for it to expire. The epoch is retained so the next holder
+// receives a higher one.
+func (l *Lease) Release(ctx context.Context) error {
+	l.renewMu.Lock()
+	defer l.renewMu.Unlock()
+
+	l.mu.Lock()
+	cur, err := l.record, l.err
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Stop acting as the holder before the lease is visibly free.
+	l.lose(ErrReleased)
+
+	free := record{Epoch: cur.Epoch}
+	if err := l.m.kv.CompareAndSwap(ctx, l.m.key(l.resource), cur, free, false); err != nil {
+		switch maelstrom.ErrorCode(err) {
+		case maelstrom.PreconditionFailed, maelstrom.KeyDoesNotExist:
+			return nil // already taken over
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// renew is executed periodically to renew the lease in the background.
+// Failed renewals are retried on the next run until the lease expires.
+func (l *Lease) renew(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, l.m.cfg.RenewInterval)
+	defer cancel()
+	_ = l.Renew(ctx)
+	return nil
+}
+
+// lose marks the lease as no longer held & notifies waiters. Returns err.
+func (l *Lease) lose(err error) error {
+	l.mu.Lock()
+	if l.err != nil {
+		err = l.err
+		l.mu.Unlock()
+		return err
+	}
+	l.err = err
+	close(l.lost)
+	l.task.Stop()
+	l.mu.Unlock()
+
+	l.m.remove(l)
+	return err
+}
@@ -0,0 +1,236 @@
+package lease_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/lease"
+	"github.com/jepsen-io/maelstrom/demo/go/service"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+)
+
+func TestManager_TryAcquire(t *testing.T) {
+	c := newCluster(t)
+	ctx := context.Background()
+
+	l, err := c.managers[0].TryAcquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := l.Epoch(), uint64(1); got != want {
+		t.Fatalf("epoch=%d, want %d", got, want)
+	} else if !l.Valid() {
+		t.Fatal("expected lease to be valid")
+	}
+
+	// Acquiring a held lease again returns the same lease.
+	if other, err := c.managers[0].TryAcquire(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	} else if other != l {
+		t.Fatal("expected the same lease")
+	}
+
+	var held *lease.HeldError
+	if _, err := c.managers[1].TryAcquire(ctx, "foo"); !errors.As(err, &held) {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got, want := held.Holder, "n1"; got != want {
+		t.Fatalf("holder=%s, want %s", got, want)
+	}
+
+	if holder, epoch, err := c.managers[1].Holder(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	} else if holder != "n1" || epoch != 1 {
+		t.Fatalf("holder=%s epoch=%d, want n1 epoch 1", holder, epoch)
+	}
+
+	// Other resources are leased independently.
+	if l, err := c.managers[1].TryAcquire(ctx, "bar"); err != nil {
+		t.Fatal(err)
+	} else if got, want := l.Epoch(), uint64(1); got != want {
+		t.Fatalf("epoch=%d, want %d", got, want)
+	}
+}
+
+// Ensure held leases are renewed in the background.
+func TestLease_Renew(t *testing.T) {
+	c := newCluster(t)
+	ctx := context.Background()
+
+	l, err := c.managers[0].TryAcquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := c.clocks[0].Now()
+	if got, want := l.Expires(), start.Add(4*time.Second); !got.Equal(want) {
+		t.Fatalf("expires=%s, want %s", got, want)
+	}
+
+	for i := 1; i <= 3; i++ {
+		waitUntil(t, func() bool { return c.clocks[0].Timers() == 1 })
+		c.clocks[0].Advance(time.Second)
+
+		want := start.Add(time.Duration(i+4) * time.Second)
+		waitUntil(t, func() bool { return l.Expires().Equal(want) })
+	}
+
+	// The lease is still held after its original expiry.
+	if _, err := c.managers[1].TryAcquire(ctx, "foo"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// Ensure a released lease can be acquired by another node with a new epoch.
+func TestLease_Release(t *testing.T) {
+	c := newCluster(t)
+	ctx := context.Background()
+
+	l, err := c.managers[0].TryAcquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if err := l.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-l.Lost():
+	default:
+		t.Fatal("expected lost channel to be closed")
+	}
+	if err := l.Err(); err != lease.ErrReleased {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := l.Renew(ctx); err != lease.ErrReleased {
+		t.Fatalf("unexpected error: %v", err)
+	} else if l.Valid() {
+		t.Fatal("expected lease to be invalid")
+	}
+
+	if holder, _, err := c.managers[1].Holder(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	} else if holder != "" {
+		t.Fatalf("holder=%s, want none", holder)
+	}
+
+	other, err := c.managers[1].TryAcquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := other.Epoch(), uint64(2); got != want {
+		t.Fatalf("epoch=%d, want %d", got, want)
+	}
+}
+
+// Ensure a lease is lost once it expires without being renewed.
+func TestLease_Expire(t *testing.T) {
+	c := newCluster(t)
+	ctx := context.Background()
+
+	l, err := c.managers[0].TryAcquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Advancing past the expiry fires the renewal too late.
+	waitUntil(t, func() bool { return c.clocks[0].Timers() == 1 })
+	c.clocks[0].Advance(5 * time.Second)
+
+	select {
+	case <-l.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for lease loss")
+	}
+	if err := l.Err(); err != lease.ErrLost {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a holder which misses the expiry of its lease learns that it has
+// been taken over on its next renewal & that its epoch is fenced off.
+func TestLease_TakeOver(t *testing.T) {
+	c := newCluster(t)
+	ctx := context.Background()
+
+	old, err := c.managers[0].TryAcquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only n2 observes the lease expiring.
+	c.clocks[1].Advance(5 * time.Second)
+	l, err := c.managers[1].Acquire(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := l.Epoch(), uint64(2); got != want {
+		t.Fatalf("epoch=%d, want %d", got, want)
+	}
+
+	// Requests from the new holder fence off the old one.
+	fence := lease.NewFence()
+	if err := fence.Check("foo", l.Epoch()); err != nil {
+		t.Fatal(err)
+	} else if err := fence.Check("foo", old.Epoch()); maelstrom.ErrorCode(err) != maelstrom.PreconditionFailed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !old.Valid() {
+		t.Fatal("expected old lease to still appear valid")
+	} else if err := old.Renew(ctx); err != lease.ErrLost {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-old.Lost():
+	default:
+		t.Fatal("expected lost channel to be closed")
+	}
+}
+
+// cluster is a pair of nodes, each with its own clock, sharing a lin-kv
+// service.
+type cluster struct {
+	managers []*lease.Manager
+	clocks   []*maelstrom.ManualClock
+}
+
+func newCluster(tb testing.TB) *cluster {
+	tb.Helper()
+
+	c := &cluster{}
+	net := simnet.NewNetwork()
+	net.AddService(maelstrom.LinKV, service.NewLinKV())
+	for _, id := range []string{"n1", "n2"} {
+		n := maelstrom.NewNode()
+		clock := maelstrom.NewManualClock(time.Unix(0, 0))
+		n.Clock = clock
+		net.AddNode(id, n)
+
+		c.managers = append(c.managers, lease.NewManager(n, maelstrom.NewLinKV(n), lease.Config{
+			Duration:      4 * time.Second,
+			RenewInterval: time.Second,
+		}))
+		c.clocks = append(c.clocks, clock)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if err := net.Close(); err != nil {
+			tb.Error(err)
+		}
+	})
+	return c
+}
+
+// waitUntil polls fn until it returns true or fails the test after 5s.
+func waitUntil(tb testing.TB, fn func() bool) {
+	tb.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			tb.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
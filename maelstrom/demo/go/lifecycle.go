@@ -0,0 +1,82 @@
+package maelstrom
+
+import (
+	"context"
+)
+
+// Initialized returns a channel which is closed once the node has handled
+// the "init" message, including any registered "init" handler. The node's ID
+// & cluster membership are available once it is closed.
+func (n *Node) Initialized() <-chan struct{} {
+	return n.initialized
+}
+
+// WaitInit blocks until the node has handled the "init" message. Returns an
+// error if ctx is done or the node shuts down first.
+func (n *Node) WaitInit(ctx context.Context) error {
+	select {
+	case <-n.initialized:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-n.ctx.Done():
+		return n.ctx.Err()
+	}
+}
+
+// OnInit registers fn to be executed with Go() once the node has handled the
+// "init" message. This is the place to start background work which depends
+// on the node ID, such as periodic gossip. If the node is already
+// initialized then fn is started immediately.
+func (n *Node) OnInit(fn func(ctx context.Context) error) {
+	n.mu.Lock()
+	if !n.initDone {
+		n.onInit = append(n.onInit, fn)
+		n.mu.Unlock()
+		return
+	}
+	n.mu.Unlock()
+
+	n.Go(fn)
+}
+
+// OnShutdown registers fn to be called when the node shuts down, such as
+// when STDIN is closed. Callbacks execute in the order they were registered,
+// after in-flight handlers & background workers have finished and before
+// Run() returns. Messages sent by fn are still delivered.
+func (n *Node) OnShutdown(fn func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onShutdown = append(n.onShutdown, fn)
+}
+
+// markInitialized resolves Initialized() and starts OnInit callbacks. Only
+// the first call has an effect.
+func (n *Node) markInitialized() {
+	n.mu.Lock()
+	if n.initDone {
+		n.mu.Unlock()
+		return
+	}
+	n.initDone = true
+	fns := n.onInit
+	n.onInit = nil
+	n.mu.Unlock()
+
+	close(n.initialized)
+	for _, fn := range fns {
+		n.Go(fn)
+	}
+}
+
+// shutdown executes OnShutdown callbacks.
+func (n *Node) shutdown() {
+	n.mu.Lock()
+	fns := n.onShutdown
+	n.onShutdown = nil
+	n.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
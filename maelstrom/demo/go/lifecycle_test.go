@@ -0,0 +1,140 @@
+package maelstrom_test
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestNode_WaitInit(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+
+		select {
+		case <-n.Initialized():
+			t.Fatal("expected node to be uninitialized")
+		default:
+		}
+
+		errorCh := make(chan error, 1)
+		go func() { errorCh <- n.WaitInit(context.Background()) }()
+
+		initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+		select {
+		case err := <-errorCh:
+			if err != nil {
+				t.Fatal(err)
+			} else if got, want := n.ID(), "n1"; got != want {
+				t.Fatalf("id=%s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for init")
+		}
+	})
+
+	t.Run("ErrContextCanceled", func(t *testing.T) {
+		n := maelstrom.NewNode()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := n.WaitInit(ctx); err != context.Canceled {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ErrShutdown", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader("")
+		n.Stdout = io.Discard
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		} else if err := n.WaitInit(context.Background()); err != context.Canceled {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestNode_OnInit(t *testing.T) {
+	n, stdin, stdout := newNode(t)
+
+	// Ensure callbacks are started after init with the node ID available.
+	ids := make(chan string, 2)
+	n.OnInit(func(ctx context.Context) error {
+		ids <- n.ID()
+		<-ctx.Done()
+		return nil
+	})
+
+	initNode(t, n, "n1", []string{"n1"}, stdin, stdout)
+
+	// Ensure callbacks registered after init are started immediately.
+	n.OnInit(func(ctx context.Context) error {
+		ids <- n.ID() + "-late"
+		return nil
+	})
+
+	got := make([]string, 0, 2)
+	for len(got) < 2 {
+		select {
+		case id := <-ids:
+			got = append(got, id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for init callbacks")
+		}
+	}
+	sort.Strings(got)
+	if want := []string{"n1", "n1-late"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ids=%v, want %v", got, want)
+	}
+}
+
+func TestNode_OnShutdown(t *testing.T) {
+	var stdout strings.Builder
+	n := maelstrom.NewNode()
+	n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"init", "msg_id":1, "node_id":"n1", "node_ids":["n1"]}}` + "\n")
+	n.Stdout = &stdout
+
+	var mu sync.Mutex
+	var calls []string
+	n.OnInit(func(ctx context.Context) error {
+		<-ctx.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, "worker")
+		return nil
+	})
+
+	// Ensure callbacks run in order after workers finish & can still send.
+	n.OnShutdown(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, "a")
+	})
+	n.OnShutdown(func() {
+		mu.Lock()
+		calls = append(calls, "b")
+		mu.Unlock()
+
+		if err := n.Send("c1", maelstrom.MessageBody{Type: "bye"}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if err := n.Run(); err != nil {
+		t.Fatal(err)
+	} else if want := []string{"worker", "a", "b"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls=%v, want %v", calls, want)
+	} else if got, want := stdout.String(), ``+
+		`{"src":"n1","dest":"c1","body":{"in_reply_to":1,"type":"init_ok"}}`+"\n"+
+		`{"src":"n1","dest":"c1","body":{"type":"bye"}}`+"\n"; got != want {
+		t.Fatalf("stdout=%s, want %s", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+package maelstrom
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// LogicalClock is a logical clock whose timestamps are piggybacked on the
+// bodies of messages exchanged between nodes. Implementations must be safe
+// for concurrent use.
+type LogicalClock interface {
+	// Field returns the name of the body field which carries timestamps.
+	Field() string
+
+	// Send advances the clock for a sent message and returns the encoded
+	// timestamp to attach to it.
+	Send() (json.RawMessage, error)
+
+	// Receive merges the encoded timestamp of a received message into the
+	// clock.
+	Receive(ts json.RawMessage) error
+}
+
+// Ordering is the causal relationship between two timestamps.
+type Ordering int
+
+const (
+	Concurrent Ordering = iota // neither happened before the other
+	Before                     // happened before the other
+	After                      // happened after the other
+	Equal                      // identical timestamps
+)
+
+// String returns the name of the ordering.
+func (o Ordering) String() string {
+	switch o {
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Equal:
+		return "equal"
+	default:
+		return "concurrent"
+	}
+}
+
+// LamportClock is a LogicalClock which maintains a single counter. If event
+// a happened before event b then a's timestamp is less than b's, however the
+// converse does not hold. Timestamps are carried in the "lamport" field.
+type LamportClock struct {
+	mu sync.Mutex
+	t  uint64
+}
+
+// NewLamportClock returns a new instance of LamportClock.
+func NewLamportClock() *LamportClock {
+	return &LamportClock{}
+}
+
+// Field returns "lamport".
+func (c *LamportClock) Field() string { return "lamport" }
+
+// Time returns the current time of the clock.
+func (c *LamportClock) Time() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Tick advances the clock for a local event and returns the new time.
+func (c *LamportClock) Tick() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t++
+	return c.t
+}
+
+// Send advances the clock and returns the new time.
+func (c *LamportClock) Send() (json.RawMessage, error) {
+	return json.Marshal(c.Tick())
+}
+
+// Receive advances the clock past the received time.
+func (c *LamportClock) Receive(ts json.RawMessage) error {
+	var t uint64
+	if err := json.Unmarshal(ts, &t); err != nil {
+		return fmt.Errorf("unmarshal lamport timestamp: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t > c.t {
+		c.t = t
+	}
+	c.t++
+	return nil
+}
+
+// Timestamp returns the Lamport timestamp carried by msg. Returns zero if
+// the message has no timestamp.
+func (c *LamportClock) Timestamp(msg Message) (uint64, error) {
+	var body struct {
+		T uint64 `json:"lamport"`
+	}
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return 0, err
+	}
+	return body.T, nil
+}
+
+// Vector is a vector clock timestamp, mapping node IDs to counters. Missing
+// entries are zero.
+type Vector map[string]uint64
+
+// Compare returns the causal relationship of v to other.
+func (v Vector) Compare(other Vector) Ordering {
+	var less, greater bool
+	for id, a := range v {
+		if b := other[id]; a < b {
+			less = true
+		} else if a > b {
+			greater = true
+		}
+	}
+	for id, b := range other {
+		if _, ok := v[id]; !ok && b > 0 {
+			less = true
+		}
+	}
+
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	default:
+		return Equal
+	}
+}
+
+// HappensBefore returns true if v happened before other.
+func (v Vector) HappensBefore(other Vector) bool {
+	return v.Compare(other) == Before
+}
+
+// Clone returns a copy of v.
+func (v Vector) Clone() Vector {
+	other := make(Vector, len(v))
+	for id, c := range v {
+		other[id] = c
+	}
+	return other
+}
+
+// String returns the vector sorted by node ID, e.g. "{n1:2 n2:1}".
+func (v Vector) String() string {
+	ids := make([]string, 0, len(v))
+	for id := range v {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	a := make([]string, len(ids))
+	for i, id := range ids {
+		a[i] = fmt.Sprintf("%s:%d", id, v[id])
+	}
+	return "{" + strings.Join(a, " ") + "}"
+}
+
+// VectorClock is a LogicalClock which maintains a counter per node. Unlike
+// a LamportClock, it can determine whether two events are concurrent.
+// Timestamps are carried in the "vclock" field.
+type VectorClock struct {
+	mu sync.Mutex
+	n  *Node
+	v  Vector
+}
+
+// NewVectorClock returns a new vector clock which counts events under the ID
+// of node n.
+func NewVectorClock(n *Node) *VectorClock {
+	return &VectorClock{n: n, v: make(Vector)}
+}
+
+// Field returns "vclock".
+func (c *VectorClock) Field() string { return "vclock" }
+
+// Time returns a copy of the current time of the clock.
+func (c *VectorClock) Time() Vector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v.Clone()
+}
+
+// Tick advances the clock for a local event and returns the new time.
+func (c *VectorClock) Tick() Vector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.v[c.n.ID()]++
+	return c.v.Clone()
+}
+
+// Send advances the clock and returns the new time.
+func (c *VectorClock) Send() (json.RawMessage, error) {
+	return json.Marshal(c.Tick())
+}
+
+// Receive merges the received time into the clock and advances it.
+func (c *VectorClock) Receive(ts json.RawMessage) error {
+	var v Vector
+	if err := json.Unmarshal(ts, &v); err != nil {
+		return fmt.Errorf("unmarshal vector timestamp: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, t := range v {
+		if t > c.v[id] {
+			c.v[id] = t
+		}
+	}
+	c.v[c.n.ID()]++
+	return nil
+}
+
+// Timestamp returns the vector timestamp carried by msg. Returns nil if the
+// message has no timestamp.
+func (c *VectorClock) Timestamp(msg Message) (Vector, error) {
+	var body struct {
+		V Vector `json:"vclock"`
+	}
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return nil, err
+	}
+	return body.V, nil
+}
+
+// stamp attaches a timestamp from the node's logical clock to a body sent to
+// another node in the cluster.
+func (n *Node) stamp(dest string, body []byte) ([]byte, error) {
+	if n.LogicalClock == nil || !n.isPeer(dest) {
+		return body, nil
+	}
+
+	ts, err := n.LogicalClock.Send()
+	if err != nil {
+		return nil, err
+	}
+	return setBodyRaw(body, n.LogicalClock.Field(), ts)
+}
+
+// merge merges the timestamp of a received message into the node's logical
+// clock, if the message carries one.
+func (n *Node) merge(msg Message) error {
+	if n.LogicalClock == nil {
+		return nil
+	}
+
+	ts, err := bodyField(msg.Body, n.LogicalClock.Field())
+	if err != nil {
+		return err
+	} else if ts == nil {
+		return nil
+	}
+	return n.LogicalClock.Receive(ts)
+}
+
+// isPeer returns true if id is another node in the cluster.
+func (n *Node) isPeer(id string) bool {
+	if id == n.ID() {
+		return false
+	}
+	for _, other := range n.NodeIDs() {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
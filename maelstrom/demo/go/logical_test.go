@@ -0,0 +1,132 @@
+package maelstrom_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestLamportClock(t *testing.T) {
+	c := maelstrom.NewLamportClock()
+	if ts, err := c.Send(); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(ts), `1`; got != want {
+		t.Fatalf("ts=%s, want %s", got, want)
+	}
+
+	// Ensure the clock advances past received timestamps.
+	if err := c.Receive(json.RawMessage(`10`)); err != nil {
+		t.Fatal(err)
+	} else if got, want := c.Time(), uint64(11); got != want {
+		t.Fatalf("time=%d, want %d", got, want)
+	}
+
+	// Ensure older timestamps still advance the clock.
+	if err := c.Receive(json.RawMessage(`3`)); err != nil {
+		t.Fatal(err)
+	} else if got, want := c.Time(), uint64(12); got != want {
+		t.Fatalf("time=%d, want %d", got, want)
+	}
+
+	if err := c.Receive(json.RawMessage(`"x"`)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestVectorClock(t *testing.T) {
+	n := maelstrom.NewNode()
+	n.Init("n1", []string{"n1", "n2", "n3"})
+
+	c := maelstrom.NewVectorClock(n)
+	if ts, err := c.Send(); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(ts), `{"n1":1}`; got != want {
+		t.Fatalf("ts=%s, want %s", got, want)
+	}
+
+	if err := c.Receive(json.RawMessage(`{"n1":0,"n2":3}`)); err != nil {
+		t.Fatal(err)
+	} else if got, want := c.Time(), (maelstrom.Vector{"n1": 2, "n2": 3}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("time=%s, want %s", got, want)
+	} else if got, want := c.Time().String(), `{n1:2 n2:3}`; got != want {
+		t.Fatalf("string=%s, want %s", got, want)
+	}
+}
+
+func TestVector_Compare(t *testing.T) {
+	for _, tt := range []struct {
+		a, b maelstrom.Vector
+		want maelstrom.Ordering
+	}{
+		{maelstrom.Vector{}, maelstrom.Vector{}, maelstrom.Equal},
+		{maelstrom.Vector{"n1": 1}, maelstrom.Vector{"n1": 1, "n2": 0}, maelstrom.Equal},
+		{maelstrom.Vector{"n1": 1}, maelstrom.Vector{"n1": 2}, maelstrom.Before},
+		{maelstrom.Vector{"n1": 1}, maelstrom.Vector{"n1": 1, "n2": 1}, maelstrom.Before},
+		{maelstrom.Vector{"n1": 2, "n2": 1}, maelstrom.Vector{"n1": 1}, maelstrom.After},
+		{maelstrom.Vector{"n1": 2}, maelstrom.Vector{"n2": 1}, maelstrom.Concurrent},
+	} {
+		if got := tt.a.Compare(tt.b); got != tt.want {
+			t.Errorf("%s vs %s=%s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if !(maelstrom.Vector{"n1": 1}).HappensBefore(maelstrom.Vector{"n1": 2}) {
+		t.Fatal("expected happens before")
+	}
+}
+
+// Ensure messages to peers are stamped & received timestamps are merged.
+func TestNode_LogicalClock(t *testing.T) {
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	stdout := bufio.NewReader(outr)
+
+	clock := maelstrom.NewLamportClock()
+	n := maelstrom.NewNode()
+	n.Stdin, n.Stdout = inr, outw
+	n.LogicalClock = clock
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		if ts, err := clock.Timestamp(msg); err != nil {
+			return err
+		} else if ts != 10 {
+			t.Errorf("timestamp=%d, want 10", ts)
+		} else if got, want := clock.Time(), uint64(11); got != want {
+			t.Errorf("time=%d, want %d", got, want)
+		}
+
+		// Messages to clients are not stamped.
+		if err := n.Send("c1", maelstrom.MessageBody{Type: "bar"}); err != nil {
+			return err
+		}
+		return n.Reply(msg, maelstrom.MessageBody{Type: "foo_ok"})
+	})
+
+	done := make(chan error)
+	go func() { done <- n.Run() }()
+	defer func() {
+		inw.Close()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	initNode(t, n, "n1", []string{"n1", "n2"}, inw, stdout)
+
+	if _, err := inw.Write([]byte(`{"src":"n2", "dest":"n1", "body":{"type":"foo", "msg_id":1, "lamport":10}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`{"src":"n1","dest":"c1","body":{"type":"bar"}}` + "\n",
+		`{"src":"n1","dest":"n2","body":{"in_reply_to":1,"lamport":12,"type":"foo_ok"}}` + "\n",
+	} {
+		if line, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if line != want {
+			t.Fatalf("line=%s, want %s", line, want)
+		}
+	}
+}
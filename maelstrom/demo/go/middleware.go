@@ -0,0 +1,37 @@
+package maelstrom
+
+// Middleware wraps a HandlerFunc with additional behavior such as logging,
+// metrics or validation. A middleware may inspect or modify the message before
+// calling next, or return without calling next to drop the message.
+//
+// The same signature is used for inbound middleware registered with Use and
+// outbound middleware registered with UseOutbound.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Use registers middleware which wraps every inbound message handler,
+// including the "init" handler and RPC response callbacks. Callbacks can be
+// distinguished by a non-zero "in_reply_to" field in the message body.
+//
+// Middleware is applied in the order it is registered so the first middleware
+// is the outermost. It must be registered before calling Run().
+func (n *Node) Use(mw ...Middleware) {
+	n.middleware = append(n.middleware, mw...)
+}
+
+// UseOutbound registers middleware which wraps every message sent by the
+// node, including replies & RPC requests. Messages passed to outbound
+// middleware have their "msg_id" & "in_reply_to" fields set.
+//
+// Middleware is applied in the order it is registered so the first middleware
+// is the outermost. It must be registered before calling Run().
+func (n *Node) UseOutbound(mw ...Middleware) {
+	n.outbound = append(n.outbound, mw...)
+}
+
+// chain wraps h with middleware so that the first middleware is outermost.
+func chain(h HandlerFunc, mw []Middleware) HandlerFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
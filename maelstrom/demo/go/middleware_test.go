@@ -0,0 +1,138 @@
+package maelstrom_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Ensure inbound middleware wraps handlers & callbacks in registration order.
+func TestNode_Use(t *testing.T) {
+	n, stdin, stdout := newNode(t)
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) maelstrom.Middleware {
+		return func(next maelstrom.HandlerFunc) maelstrom.HandlerFunc {
+			return func(msg maelstrom.Message) error {
+				mu.Lock()
+				calls = append(calls, name+":"+msg.Type())
+				mu.Unlock()
+				return next(msg)
+			}
+		}
+	}
+	n.Use(record("a"), record("b"))
+
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		resp, err := n.SyncRPC(msg.Context(), "n2", map[string]any{"type": "bar"})
+		if err != nil {
+			return err
+		}
+		return n.Reply(msg, map[string]any{"type": "foo_ok", "bar": json.RawMessage(resp.Body)})
+	})
+
+	initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+	if _, err := stdin.Write([]byte(`{"src":"c1","dest":"n1","body":{"type":"foo","msg_id":1}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	} else if got, want := line, `{"src":"n1","dest":"n2","body":{"msg_id":1,"type":"bar"}}`+"\n"; got != want {
+		t.Fatalf("request=%s, want %s", got, want)
+	}
+
+	if _, err := stdin.Write([]byte(`{"src":"n2","dest":"n1","body":{"type":"bar_ok","in_reply_to":1}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := strings.Join(calls, ","), "a:init,b:init,a:foo,b:foo,a:bar_ok,b:bar_ok"; got != want {
+		t.Fatalf("calls=%s, want %s", got, want)
+	}
+}
+
+// Ensure inbound middleware can reject a message before its handler.
+func TestNode_Use_Reject(t *testing.T) {
+	n, stdin, stdout := newNode(t)
+	n.Use(func(next maelstrom.HandlerFunc) maelstrom.HandlerFunc {
+		return func(msg maelstrom.Message) error {
+			if msg.Src == "c2" {
+				return maelstrom.NewRPCError(maelstrom.Abort, "unauthorized")
+			}
+			return next(msg)
+		}
+	})
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		return n.Reply(msg, map[string]any{"type": "foo_ok"})
+	})
+	initNode(t, n, "n1", []string{"n1"}, stdin, stdout)
+
+	if _, err := stdin.Write([]byte(`{"src":"c2","dest":"n1","body":{"type":"foo","msg_id":2}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	} else if got, want := line, `{"src":"n1","dest":"c2","body":{"code":14,"in_reply_to":2,"text":"unauthorized","type":"error"}}`+"\n"; got != want {
+		t.Fatalf("response=%s, want %s", got, want)
+	}
+}
+
+// Ensure outbound middleware wraps every sent message.
+func TestNode_UseOutbound(t *testing.T) {
+	n, stdin, stdout := newNode(t)
+
+	// Drop gossip messages & tag all other messages.
+	n.UseOutbound(func(next maelstrom.HandlerFunc) maelstrom.HandlerFunc {
+		return func(msg maelstrom.Message) error {
+			if msg.Type() == "gossip" {
+				return nil
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(msg.Body, &body); err != nil {
+				return err
+			}
+			body["tag"] = "x"
+
+			buf, err := json.Marshal(body)
+			if err != nil {
+				return err
+			}
+			msg.Body = buf
+			return next(msg)
+		}
+	})
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		if err := n.Send("n2", map[string]any{"type": "gossip"}); err != nil {
+			return err
+		}
+		return n.RPC("n2", map[string]any{"type": "bar"}, func(msg maelstrom.Message) error { return nil })
+	})
+
+	if _, err := stdin.Write([]byte(`{"body":{"type":"init","msg_id":1,"node_id":"n1","node_ids":["n1","n2"]}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	} else if got, want := line, `{"src":"n1","body":{"in_reply_to":1,"tag":"x","type":"init_ok"}}`+"\n"; got != want {
+		t.Fatalf("init_ok=%s, want %s", got, want)
+	}
+
+	if _, err := stdin.Write([]byte(`{"src":"c1","dest":"n1","body":{"type":"foo","msg_id":2}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	} else if got, want := line, `{"src":"n1","dest":"n2","body":{"msg_id":1,"tag":"x","type":"bar"}}`+"\n"; got != want {
+		t.Fatalf("request=%s, want %s", got, want)
+	}
+}
@@ -2,12 +2,17 @@ package maelstrom
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"sync"
 )
 
@@ -16,35 +21,92 @@ type Node struct {
 	mu sync.Mutex
 	wg sync.WaitGroup
 
+	ctx    context.Context
+	cancel context.CancelFunc
+	err    error // first fatal error, returned by Run
+
+	initialized chan struct{} // closed once "init" is handled
+	initDone    bool
+	onInit      []func(ctx context.Context) error
+	onShutdown  []func()
+
+	idMu      sync.RWMutex // guards id & nodeIDs
 	id        string
 	nodeIDs   []string
 	nextMsgID int
 
-	handlers  map[string]HandlerFunc
-	callbacks map[int]HandlerFunc
+	handlers       map[string]HandlerFunc
+	defaultHandler HandlerFunc
+	callbacks      map[int]HandlerFunc
+
+	middleware []Middleware // inbound
+	outbound   []Middleware
+
+	stats *statsRecorder
+
+	outMu    sync.RWMutex  // guards out
+	out      *outputWriter // only set during Run()
+	stdoutMu sync.Mutex    // synchronizes writes to Stdout outside Run()
 
 	// Stdin is for reading messages in from the Maelstrom network.
 	Stdin io.Reader
 
 	// Stdout is for writing messages out to the Maelstrom network.
 	Stdout io.Writer
+
+	// OutputQueueSize is the number of outgoing messages which can be queued
+	// for writing to STDOUT while Run() is executing. Senders block while the
+	// queue is full. Defaults to DefaultOutputQueueSize.
+	OutputQueueSize int
+
+	// Dispatcher schedules the execution of request handlers. Defaults to a
+	// GoroutineDispatcher. Must be set before calling Run().
+	Dispatcher Dispatcher
+
+	// Clock provides the time for scheduled tasks & retry backoffs. Defaults
+	// to SystemClock. Must be set before calling Run().
+	Clock Clock
+
+	// LogicalClock, if set, stamps messages sent to other nodes in the
+	// cluster with a timestamp & merges the timestamps of received messages
+	// before they are handled. Must be set before calling Run().
+	LogicalClock LogicalClock
+
+	// MaxPanics is the number of handler & callback panics after which the
+	// node shuts down and Run() returns an error. Panics are always recovered
+	// and replied to with a Crash error. Zero means no limit.
+	MaxPanics int
+
+	// MaxMessageSize is the maximum size, in bytes, of a message read from
+	// STDIN. Larger messages are skipped and rejected with a MalformedRequest
+	// error when their sender can be determined. Zero means no limit.
+	MaxMessageSize int
 }
 
 // NewNode returns a new instance of Node connected to STDIN/STDOUT.
 func NewNode() *Node {
-	return &Node{
-		handlers:  make(map[string]HandlerFunc),
-		callbacks: make(map[int]HandlerFunc),
-
-		Stdin:  os.Stdin,
-		Stdout: os.Stdout,
+	n := &Node{
+		handlers:    make(map[string]HandlerFunc),
+		callbacks:   make(map[int]HandlerFunc),
+		stats:       newStatsRecorder(),
+		initialized: make(chan struct{}),
+
+		Stdin:           os.Stdin,
+		Stdout:          os.Stdout,
+		OutputQueueSize: DefaultOutputQueueSize,
+		Dispatcher:      NewGoroutineDispatcher(),
+		Clock:           SystemClock{},
 	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	return n
 }
 
 // Init is used for initializing the node. This is normally called after
 // receiving an "init" message but it can also be called manually when
-// initializing unit tests.
+// initializing unit tests. Calling Init does not resolve Initialized().
 func (n *Node) Init(id string, nodeIDs []string) {
+	n.idMu.Lock()
+	defer n.idMu.Unlock()
 	n.id = id
 	n.nodeIDs = nodeIDs
 }
@@ -52,6 +114,8 @@ func (n *Node) Init(id string, nodeIDs []string) {
 // ID returns the identifier for this node.
 // Only valid after "init" message has been received.
 func (n *Node) ID() string {
+	n.idMu.RLock()
+	defer n.idMu.RUnlock()
 	return n.id
 }
 
@@ -59,9 +123,53 @@ func (n *Node) ID() string {
 // local node ID and is the same order across all nodes. Only valid after "init"
 // message has been received.
 func (n *Node) NodeIDs() []string {
+	n.idMu.RLock()
+	defer n.idMu.RUnlock()
 	return n.nodeIDs
 }
 
+// Context returns a context which is cancelled when the node shuts down. This
+// occurs when STDIN is closed, when a fatal error occurs or when the context
+// passed to RunContext() is cancelled.
+func (n *Node) Context() context.Context {
+	return n.ctx
+}
+
+// Go executes fn in a background goroutine supervised by the node. The
+// context passed to fn is cancelled when the node shuts down and Run() waits
+// for fn to return before returning itself.
+//
+// If fn returns an error other than a context cancellation then the node is
+// shut down and Run() returns the error. A panic in fn is also treated as a
+// fatal error.
+func (n *Node) Go(fn func(ctx context.Context) error) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Panic in background worker: %v\n%s", r, debug.Stack())
+				n.fail(fmt.Errorf("panic in background worker: %v", r))
+			}
+		}()
+		if err := fn(n.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			n.fail(err)
+		}
+	}()
+}
+
+// fail records err as a fatal error and shuts down the node. Only the first
+// fatal error is retained.
+func (n *Node) fail(err error) {
+	n.mu.Lock()
+	if n.err == nil {
+		n.err = err
+	}
+	n.mu.Unlock()
+
+	n.cancel()
+}
+
 // Handle registers a message handler for a given message type. Will panic if
 // registering multiple handlers for the same message type.
 func (n *Node) Handle(typ string, fn HandlerFunc) {
@@ -71,96 +179,413 @@ func (n *Node) Handle(typ string, fn HandlerFunc) {
 	n.handlers[typ] = fn
 }
 
+// HandleDefault registers a handler for messages which have no handler
+// registered for their type. Will panic if registering multiple default
+// handlers.
+//
+// Without a default handler, Run() returns an error when it receives a
+// message it cannot handle. NotSupportedHandler and IgnoreHandler can be
+// used to keep the node running instead.
+func (n *Node) HandleDefault(fn HandlerFunc) {
+	if n.defaultHandler != nil {
+		panic("duplicate default message handler")
+	}
+	n.defaultHandler = fn
+}
+
+// NotSupportedHandler is a default handler which replies to requests with a
+// NotSupported error. Messages without a "msg_id" cannot be replied to so
+// they are logged & ignored.
+func NotSupportedHandler(msg Message) error {
+	var body MessageBody
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	} else if body.MsgID == 0 {
+		return IgnoreHandler(msg)
+	}
+	return NewRPCError(NotSupported, fmt.Sprintf("message type %q not supported", body.Type))
+}
+
+// IgnoreHandler is a default handler which logs & ignores messages.
+func IgnoreHandler(msg Message) error {
+	log.Printf("Ignoring unhandled %q message from %s", msg.Type(), msg.Src)
+	return nil
+}
+
 // Run executes the main event handling loop. It reads in messages from STDIN
 // and delegates them to the appropriate registered handler. This should be
 // the last function executed by main().
 func (n *Node) Run() error {
-	scanner := bufio.NewScanner(n.Stdin)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-
-		// Parse next line from STDIN as a JSON-formatted message.
-		var msg Message
-		if err := json.Unmarshal(line, &msg); err != nil {
-			return fmt.Errorf("unmarshal message: %w", err)
-		}
+	return n.RunContext(context.Background())
+}
 
-		var body MessageBody
-		if err := json.Unmarshal(msg.Body, &body); err != nil {
-			return fmt.Errorf("unmarshal message body: %w", err)
+// RunContext executes the main event handling loop until STDIN is closed, a
+// fatal error occurs or ctx is cancelled. The node's context is then
+// cancelled and RunContext waits for in-flight handlers & background workers
+// to finish. A node can only be run once.
+//
+// Returns the first fatal error, if any, or the error from ctx if it was
+// cancelled.
+func (n *Node) RunContext(ctx context.Context) error {
+	// Shut down the node if the parent context is cancelled.
+	go func() {
+		select {
+		case <-ctx.Done():
+			n.cancel()
+		case <-n.ctx.Done():
 		}
-		log.Printf("Received %s", msg)
-
-		// What handler should we use for this message?
-		if body.InReplyTo != 0 {
-			// Extract callback, if replying to a previous message.
-			n.mu.Lock()
-			h := n.callbacks[body.InReplyTo]
-			delete(n.callbacks, body.InReplyTo)
-			n.mu.Unlock()
-
-			// If no callback exists, just log a message and skip.
-			if h == nil {
-				log.Printf("Ignoring reply to %d with no callback", body.InReplyTo)
+	}()
+
+	// Write messages from a separate goroutine while running.
+	n.outMu.Lock()
+	n.out = newOutputWriter(n.Stdout, n.OutputQueueSize, n.fail)
+	n.outMu.Unlock()
+
+	if err := n.loop(); err != nil {
+		n.fail(err)
+	}
+	n.cancel()
+
+	// Wait for all in-flight handlers & background workers to complete.
+	n.wg.Wait()
+	n.Dispatcher.Close()
+	n.shutdown()
+
+	// Flush remaining messages. Any later messages are written synchronously.
+	n.outMu.Lock()
+	if err := n.out.close(); err != nil {
+		n.fail(err)
+	}
+	n.out = nil
+	n.outMu.Unlock()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	return ctx.Err()
+}
+
+// loop reads messages from STDIN and dispatches them until STDIN is closed
+// or the node is shut down.
+func (n *Node) loop() error {
+	// Read in a separate goroutine so that shutdown is not blocked on STDIN.
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(n.Stdin)
+		for {
+			line, size, err := readLine(r, n.MaxMessageSize)
+			if err == io.EOF {
+				readErr <- nil
+				return
+			} else if err != nil {
+				readErr <- err
+				return
+			}
+
+			if n.MaxMessageSize > 0 && size > n.MaxMessageSize {
+				n.rejectOversized(line, size)
 				continue
 			}
 
-			// Handle callback in a separate goroutine.
-			n.wg.Add(1)
-			go func() {
-				defer n.wg.Done()
-				n.handleCallback(h, msg)
-			}()
-			continue
+			select {
+			case lines <- line:
+			case <-n.ctx.Done():
+				return
+			}
 		}
+	}()
 
-		// If this is not a callback, ensure that a handler is registered.
-		var h HandlerFunc
-		if body.Type == "init" {
-			h = n.handleInitMessage // wraps init message with special handling.
-		} else if h = n.handlers[body.Type]; h == nil {
-			return fmt.Errorf("No handler for %s", line)
+	for {
+		select {
+		case <-n.ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case line := <-lines:
+			if err := n.dispatch(line); err != nil {
+				return err
+			}
 		}
+	}
+}
+
+// dispatch parses a single line from STDIN and executes its handler or
+// callback in a separate goroutine.
+func (n *Node) dispatch(line []byte) error {
+	// Parse next line from STDIN as a JSON-formatted message.
+	var msg Message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return fmt.Errorf("unmarshal message: %w", err)
+	}
+
+	body, err := msg.header()
+	if err != nil {
+		return fmt.Errorf("unmarshal message body: %w", err)
+	}
+	log.Printf("Received %s", msg)
+	n.stats.received(body.Type, msg.Src, len(line))
+
+	// Merge the sender's logical timestamp before the message is handled.
+	if err := n.merge(msg); err != nil {
+		log.Printf("logical clock error: %s", err)
+	}
 
-		// Handle message in a separate goroutine.
+	// What handler should we use for this message?
+	if body.InReplyTo != 0 {
+		// Extract callback, if replying to a previous message.
+		n.mu.Lock()
+		h := n.callbacks[body.InReplyTo]
+		delete(n.callbacks, body.InReplyTo)
+		n.mu.Unlock()
+
+		// If no callback exists, just log a message and skip.
+		if h == nil {
+			log.Printf("Ignoring reply to %d with no callback", body.InReplyTo)
+			return nil
+		}
+
+		// Handle callback in a separate goroutine.
+		h = chain(h, n.middleware)
+		msg.ctx = n.ctx
 		n.wg.Add(1)
 		go func() {
 			defer n.wg.Done()
-			n.handleMessage(h, msg)
+			n.handleCallback(h, msg)
 		}()
+		return nil
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+
+	// If this is not a callback, ensure that a handler is registered.
+	var h HandlerFunc
+	if body.Type == "init" {
+		h = n.handleInitMessage // wraps init message with special handling.
+	} else if h = n.handlers[body.Type]; h == nil && body.Type == "stats" {
+		h = n.handleStatsMessage
+	} else if h == nil && n.defaultHandler != nil {
+		h = n.defaultHandler
+	} else if h == nil {
+		return fmt.Errorf("No handler for %s", line)
 	}
+	h = chain(h, n.middleware)
 
-	// Wait for all in-flight handlers to complete.
-	n.wg.Wait()
+	// Schedule the handler with the dispatcher. The message context is
+	// cancelled once the handler returns.
+	n.wg.Add(1)
+	if err := n.Dispatcher.Dispatch(msg, func() {
+		defer n.wg.Done()
 
+		ctx, cancel := context.WithCancel(n.ctx)
+		defer cancel()
+		msg.ctx = ctx
+
+		n.handleMessage(h, msg)
+	}); err != nil {
+		n.wg.Done()
+		log.Printf("Rejecting %s message: %s", body.Type, err)
+		n.replyError(msg, NewRPCError(TemporarilyUnavailable, err.Error()))
+	}
 	return nil
 }
 
+// readLine reads the next newline-delimited line from r and returns it along
+// with its full size. If max is positive, only the first max bytes of the
+// line are retained. Returns io.EOF once no more lines are available.
+func readLine(r *bufio.Reader, max int) (line []byte, size int, err error) {
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			line = appendPrefix(line, chunk, max)
+			size += len(chunk)
+			continue
+		} else if err == io.EOF && size+len(chunk) > 0 {
+			err = nil // final line without a trailing newline
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		chunk = bytes.TrimSuffix(bytes.TrimSuffix(chunk, []byte("\n")), []byte("\r"))
+		return appendPrefix(line, chunk, max), size + len(chunk), nil
+	}
+}
+
+// appendPrefix appends chunk to line without growing line beyond max bytes.
+func appendPrefix(line, chunk []byte, max int) []byte {
+	if max > 0 && len(line)+len(chunk) > max {
+		chunk = chunk[:max-len(line)]
+	}
+	return append(line, chunk...)
+}
+
+// rejectOversized logs & skips a message which exceeds MaxMessageSize. If the
+// sender & message ID can be found in the retained prefix of the message
+// then a MalformedRequest error is sent back to the sender.
+func (n *Node) rejectOversized(prefix []byte, size int) {
+	text := fmt.Sprintf("message of %d bytes exceeds maximum size of %d bytes", size, n.MaxMessageSize)
+	log.Printf("Skipping %s", text)
+
+	src, msgID := peekEnvelope(prefix)
+	if src == "" || msgID == 0 {
+		return
+	}
+
+	buf, err := encodeBody(NewRPCError(MalformedRequest, text), "in_reply_to", msgID)
+	if err == nil {
+		err = n.send(src, buf)
+	}
+	if err != nil {
+		log.Printf("reply error: %s", err)
+	}
+}
+
+// peekEnvelope returns the "src" field & body "msg_id" field of a possibly
+// truncated JSON-encoded message. Fields which cannot be found are returned
+// as zero values.
+func peekEnvelope(buf []byte) (src string, msgID int) {
+	type frame struct {
+		object  bool   // true if the container is an object
+		wantKey bool   // true if the next token is an object key
+		key     string // current object key
+	}
+	var stack []frame
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return src, msgID
+		}
+
+		// Track the current key of the innermost object.
+		top := len(stack) - 1
+		if top >= 0 && stack[top].wantKey {
+			if key, ok := tok.(string); ok {
+				stack[top].key, stack[top].wantKey = key, false
+				continue
+			}
+		}
+
+		switch tok := tok.(type) {
+		case json.Delim:
+			switch tok {
+			case '{':
+				stack = append(stack, frame{object: true, wantKey: true})
+			case '[':
+				stack = append(stack, frame{})
+			default:
+				stack = stack[:top]
+				if top > 0 && stack[top-1].object {
+					stack[top-1].wantKey = true
+				}
+			}
+			continue
+
+		case string:
+			if len(stack) == 1 && stack[0].key == "src" {
+				src = tok
+			}
+
+		case json.Number:
+			if len(stack) == 2 && stack[0].key == "body" && stack[1].key == "msg_id" {
+				if v, err := tok.Int64(); err == nil {
+					msgID = int(v)
+				}
+			}
+		}
+
+		if top >= 0 && stack[top].object {
+			stack[top].wantKey = true
+		}
+	}
+}
+
 // handleCallback sends msg response to a callback function. Logs error, if one occurs.
 func (n *Node) handleCallback(h HandlerFunc, msg Message) {
-	if err := h(msg); err != nil {
+	if err := n.invoke(h, msg); err != nil {
+		n.stats.handlerError(msg.Type())
 		log.Printf("callback error: %s", err)
 	}
 }
 
 // handleMessage sends msg to a handler function. Sends an RPC error if an error is returned.
 func (n *Node) handleMessage(h HandlerFunc, msg Message) {
-	if err := h(msg); err != nil {
+	if err := n.invoke(h, msg); err != nil {
+		n.stats.handlerError(msg.Type())
+
 		switch err := err.(type) {
 		case *RPCError:
-			if err := n.Reply(msg, err); err != nil {
-				log.Printf("reply error: %s", err)
-			}
+			n.replyError(msg, err)
 		default:
-			log.Printf("Exception handling %#v:\n%s", msg, err)
-			if err := n.Reply(msg, NewRPCError(Crash, err.Error())); err != nil {
-				log.Printf("reply error: %s", err)
-			}
+			log.Printf("Exception handling %s:\n%s", msg, err)
+			n.replyError(msg, NewRPCError(Crash, err.Error()))
+		}
+	}
+}
+
+// replyError replies to msg with err. Messages without a "msg_id" cannot be
+// replied to so the error is only logged.
+func (n *Node) replyError(msg Message, err *RPCError) {
+	if body, e := msg.header(); e == nil && body.MsgID == 0 {
+		log.Printf("Dropping error for %q message from %s without msg_id: %s", body.Type, msg.Src, err)
+		return
+	}
+	if err := n.Reply(msg, err); err != nil {
+		log.Printf("reply error: %s", err)
+	}
+}
+
+// invoke executes h with msg. A panic is recovered and returned as an
+// *RPCError with a Crash code which includes a trimmed stack trace. The full
+// stack trace is logged.
+func (n *Node) invoke(h HandlerFunc, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic handling %s: %v\n%s", msg, r, debug.Stack())
+			err = NewRPCError(Crash, fmt.Sprintf("panic: %v\n%s", r, panicStack()))
+			n.recordPanic(r)
+		}
+	}()
+	return h(msg)
+}
+
+// maxPanicFrames is the number of stack frames included in Crash errors.
+const maxPanicFrames = 5
+
+// panicStack returns the innermost frames of the stack of a panicking
+// goroutine, starting at the function which panicked. Must be called from a
+// deferred function.
+func panicStack() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+
+	// Skip frames up to & including the runtime panic handler.
+	var b strings.Builder
+	var panicking bool
+	for i := 0; i < maxPanicFrames; {
+		frame, more := frames.Next()
+		if !panicking {
+			panicking = frame.Function == "runtime.gopanic"
+		} else {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			i++
+		}
+
+		if !more {
+			break
 		}
 	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// recordPanic counts a recovered panic. Shuts down the node if the number of
+// panics reaches MaxPanics.
+func (n *Node) recordPanic(r any) {
+	if count := n.stats.panic(); n.MaxPanics > 0 && count >= n.MaxPanics {
+		n.fail(fmt.Errorf("too many panics (%d), last: %v", count, r))
+	}
 }
 
 func (n *Node) handleInitMessage(msg Message) error {
@@ -178,28 +603,36 @@ func (n *Node) handleInitMessage(msg Message) error {
 	}
 
 	// Send back a response that the node has been initialized.
-	log.Printf("Node %s initialized", n.id)
-	return n.Reply(msg, MessageBody{Type: "init_ok"})
+	log.Printf("Node %s initialized", n.ID())
+	if err := n.Reply(msg, MessageBody{Type: "init_ok"}); err != nil {
+		return err
+	}
+	n.markInitialized()
+	return nil
 }
 
 // Reply replies to a request with a response body.
 func (n *Node) Reply(req Message, body any) error {
-	// Extract the message ID from the original message.
-	var reqBody MessageBody
-	if err := json.Unmarshal(req.Body, &reqBody); err != nil {
+	buf, err := json.Marshal(body)
+	if err != nil {
 		return err
 	}
+	return n.reply(req, buf)
+}
 
-	// We have to marshal/unmarshal to inject our reply message ID.
-	b := make(map[string]any)
-	if buf, err := json.Marshal(body); err != nil {
-		return err
-	} else if err := json.Unmarshal(buf, &b); err != nil {
+// reply replies to a request with an encoded response body.
+func (n *Node) reply(req Message, body []byte) error {
+	// Extract the message ID from the original message.
+	reqBody, err := req.header()
+	if err != nil {
 		return err
 	}
-	b["in_reply_to"] = reqBody.MsgID
 
-	return n.Send(req.Src, b)
+	buf, err := setBodyField(body, "in_reply_to", reqBody.MsgID)
+	if err != nil {
+		return err
+	}
+	return n.send(req.Src, buf)
 }
 
 // Send sends a message body to a given destination node.
@@ -208,68 +641,117 @@ func (n *Node) Send(dest string, body any) error {
 	if err != nil {
 		return err
 	}
+	return n.send(dest, bodyJSON)
+}
+
+// send sends an encoded message body to a given destination node.
+func (n *Node) send(dest string, body json.RawMessage) error {
+	body, err := n.stamp(dest, body)
+	if err != nil {
+		return err
+	}
 
-	buf, err := json.Marshal(Message{
-		Src:  n.id,
+	return chain(n.write, n.outbound)(Message{
+		Src:  n.ID(),
 		Dest: dest,
-		Body: bodyJSON,
+		Body: body,
 	})
+}
+
+// write writes msg to STDOUT as a single line. While Run() is executing, the
+// message is queued for the output writer. Otherwise it is written directly.
+func (n *Node) write(msg Message) error {
+	buf, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
-	// Synchronize access to STDOUT.
-	n.mu.Lock()
-	defer n.mu.Unlock()
-
 	log.Printf("Sent %s", buf)
 
-	if _, err = n.Stdout.Write(buf); err != nil {
-		return err
+	n.stats.sent(msg.peekType(), msg.Dest, len(buf))
+
+	buf = append(buf, '\n')
+
+	n.outMu.RLock()
+	defer n.outMu.RUnlock()
+	if n.out != nil {
+		return n.out.enqueue(buf)
 	}
-	_, err = n.Stdout.Write([]byte{'\n'})
+
+	n.stdoutMu.Lock()
+	defer n.stdoutMu.Unlock()
+	_, err = n.Stdout.Write(buf)
 	return err
 }
 
 // RPC sends an async RPC request. Handler invoked when response message received.
 func (n *Node) RPC(dest string, body any, handler HandlerFunc) error {
+	_, err := n.rpc(dest, body, handler)
+	return err
+}
+
+// rpc sends an async RPC request and returns the message ID of the request.
+func (n *Node) rpc(dest string, body any, handler HandlerFunc) (int, error) {
 	n.mu.Lock()
 
 	// Generate a unique message ID.
 	n.nextMsgID++
 	msgID := n.nextMsgID
 
-	// Register a handler for our callback.
-	n.callbacks[msgID] = handler
+	// Register a handler for our callback which records the RPC latency.
+	start := n.Clock.Now()
+	n.callbacks[msgID] = func(msg Message) error {
+		n.stats.rpc(dest, n.Clock.Now().Sub(start))
+		return handler(msg)
+	}
 
 	n.mu.Unlock()
 
-	// We have to marshal/unmarshal to inject our message ID.
-	b := make(map[string]any)
-	if buf, err := json.Marshal(body); err != nil {
-		return err
-	} else if err := json.Unmarshal(buf, &b); err != nil {
-		return err
+	buf, err := encodeBody(body, "msg_id", msgID)
+	if err != nil {
+		n.removeCallback(msgID)
+		return 0, err
 	}
-	b["msg_id"] = msgID
 
-	return n.Send(dest, b)
+	if err := n.send(dest, buf); err != nil {
+		n.removeCallback(msgID)
+		return 0, err
+	}
+	return msgID, nil
+}
+
+// removeCallback deregisters the callback for a message ID. Any reply that
+// arrives afterward is logged & ignored.
+func (n *Node) removeCallback(msgID int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.callbacks, msgID)
 }
 
 // SyncRPC sends a synchronous RPC request. Returns the response message. RPC
 // errors in the message body are converted to *RPCError and are returned.
+//
+// If the context deadline is exceeded before a response arrives, an *RPCError
+// with a Timeout code is returned which wraps the context error. The callback
+// is deregistered so late responses are dropped.
 func (n *Node) SyncRPC(ctx context.Context, dest string, body any) (Message, error) {
-	respCh := make(chan Message)
-	if err := n.RPC(dest, body, func(m Message) error {
+	// Buffer the channel so a reply racing with cancellation does not block.
+	respCh := make(chan Message, 1)
+	msgID, err := n.rpc(dest, body, func(m Message) error {
 		respCh <- m
 		return nil
-	}); err != nil {
+	})
+	if err != nil {
 		return Message{}, err
 	}
 
 	// Wait for either the context to finish or for the response message to arrive.
 	select {
 	case <-ctx.Done():
+		n.removeCallback(msgID)
+		if err := ctx.Err(); errors.Is(err, context.DeadlineExceeded) {
+			return Message{}, newTimeoutError(err)
+		}
 		return Message{}, ctx.Err()
 
 	case m := <-respCh:
@@ -286,25 +768,84 @@ type Message struct {
 	Src  string          `json:"src,omitempty"`
 	Dest string          `json:"dest,omitempty"`
 	Body json.RawMessage `json:"body,omitempty"`
+
+	ctx context.Context
+	hdr *messageHeader // parsed on receipt
+}
+
+// messageHeader caches the reserved fields parsed from a message body.
+type messageHeader struct {
+	raw  []byte // body the fields were parsed from
+	body MessageBody
+}
+
+// header returns the reserved fields of the message body. The fields are
+// parsed once and cached, unless the body has since been replaced.
+func (m *Message) header() (MessageBody, error) {
+	if h := m.hdr; h != nil && sameBytes(h.raw, m.Body) {
+		return h.body, nil
+	}
+
+	var body MessageBody
+	if err := json.Unmarshal(m.Body, &body); err != nil {
+		return MessageBody{}, err
+	}
+	m.hdr = &messageHeader{raw: m.Body, body: body}
+	return body, nil
+}
+
+// sameBytes returns true if a & b are the same slice of the same array.
+func sameBytes(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// Context returns the context of a received message. For requests, it is
+// cancelled when the handler returns or the node shuts down. For responses,
+// it is the node's context. Returns the background context for messages
+// which were not received by a node.
+func (m *Message) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// String returns the message encoded as JSON.
+func (m Message) String() string {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Sprintf("<invalid message: %s>", err)
+	}
+	return string(buf)
+}
+
+// peekType returns the "type" field from the message body without decoding
+// the rest of the body, unless the header has already been parsed.
+func (m *Message) peekType() string {
+	if h := m.hdr; h != nil && sameBytes(h.raw, m.Body) {
+		return h.body.Type
+	}
+	return bodyType(m.Body)
 }
 
 // Type returns the "type" field from the message body.
 // Returns blank string if field does not exist or body is malformed.
 func (m *Message) Type() string {
-	var body MessageBody
-	if err := json.Unmarshal(m.Body, &body); err != nil {
+	body, err := m.header()
+	if err != nil {
 		return ""
 	}
 	return body.Type
 }
 
-// RPCError returns the RPC error from the message body.
-// Returns a malformed body as a generic crash error.
+// RPCError returns the RPC error from the message body. Error bodies have a
+// type of "error", which is needed to detect Timeout errors as their code is
+// zero. Returns a malformed body as a generic crash error.
 func (m *Message) RPCError() *RPCError {
-	var body MessageBody
-	if err := json.Unmarshal(m.Body, &body); err != nil {
+	body, err := m.header()
+	if err != nil {
 		return NewRPCError(Crash, err.Error())
-	} else if body.Code == 0 {
+	} else if body.Type != "error" && body.Code == 0 {
 		return nil // no error
 	}
 	return NewRPCError(body.Code, body.Text)
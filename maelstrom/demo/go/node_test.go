@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -68,6 +69,55 @@ func TestNode_Run(t *testing.T) {
 			t.Fatalf("stdout=%s, want %s", got, want)
 		}
 	})
+
+	// Ensure messages beyond the default bufio.Scanner limit can be read.
+	t.Run("LargeMessage", func(t *testing.T) {
+		var stdout bytes.Buffer
+		payload := strings.Repeat("x", 1<<20)
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"echo", "msg_id":1, "echo":"` + payload + `"}}` + "\r\n")
+		n.Stdout = &stdout
+		n.Handle("echo", func(msg maelstrom.Message) error {
+			var body map[string]any
+			if err := json.Unmarshal(msg.Body, &body); err != nil {
+				return err
+			}
+			body["type"] = "echo_ok"
+			return n.Reply(msg, body)
+		})
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := stdout.String(), `{"dest":"c1","body":{"echo":"`+payload+`","in_reply_to":1,"msg_id":1,"type":"echo_ok"}}`+"\n"; got != want {
+			t.Fatalf("unexpected stdout of %d bytes", len(got))
+		}
+	})
+
+	// Ensure messages exceeding MaxMessageSize are rejected without stopping the node.
+	t.Run("ErrMessageTooLarge", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.MaxMessageSize = 100
+		n.Stdin = strings.NewReader(
+			`{"src":"c1", "body":{"type":"foo", "msg_id":1, "data":"` + strings.Repeat("x", 200) + `"}}` + "\n" +
+				`{"src":"c1", "body":{"type":"foo", "data":"` + strings.Repeat("x", 200) + `", "msg_id":2}}` + "\n" +
+				`{"src":"c1", "body":{"type":"foo", "msg_id":3}}`)
+		n.Stdout = &stdout
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			return n.Reply(msg, maelstrom.MessageBody{Type: "foo_ok"})
+		})
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The second message's ID is beyond the retained prefix so it is
+		// skipped without a reply.
+		if got, want := stdout.String(), ``+
+			`{"dest":"c1","body":{"code":12,"in_reply_to":1,"text":"message of 258 bytes exceeds maximum size of 100 bytes","type":"error"}}`+"\n"+
+			`{"dest":"c1","body":{"in_reply_to":3,"type":"foo_ok"}}`+"\n"; got != want {
+			t.Fatalf("stdout=%s, want %s", got, want)
+		}
+	})
 }
 
 // Ensure a node can handle the "init" message.
@@ -134,6 +184,120 @@ func TestNode_Run_Echo(t *testing.T) {
 	}
 }
 
+// Ensure the node context is cancelled when the node shuts down.
+func TestNode_RunContext(t *testing.T) {
+	t.Run("EOF", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader("")
+		n.Stdout = io.Discard
+
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		} else if err := n.Context().Err(); err != context.Canceled {
+			t.Fatalf("unexpected context error: %v", err)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		stdin, _ := io.Pipe()
+		n := maelstrom.NewNode()
+		n.Stdin = stdin
+		n.Stdout = io.Discard
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- n.RunContext(ctx) }()
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != context.Canceled {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for node to stop")
+		}
+	})
+}
+
+// Ensure background workers are supervised by the node.
+func TestNode_Go(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader("")
+		n.Stdout = io.Discard
+
+		// Workers are waited on until they observe the shutdown.
+		var stopped bool
+		n.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			stopped = true
+			return ctx.Err()
+		})
+
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		} else if !stopped {
+			t.Fatal("expected worker to stop before Run returns")
+		}
+	})
+
+	t.Run("Err", func(t *testing.T) {
+		stdin, _ := io.Pipe()
+		n := maelstrom.NewNode()
+		n.Stdin = stdin
+		n.Stdout = io.Discard
+
+		n.Go(func(ctx context.Context) error { return errors.New("marker") })
+		if err := n.Run(); err == nil || err.Error() != "marker" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+// Ensure handlers receive a context which is cancelled on shutdown.
+func TestMessage_Context(t *testing.T) {
+	inr, inw := io.Pipe()
+	n := maelstrom.NewNode()
+	n.Stdin = inr
+	n.Stdout = io.Discard
+
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		close(started)
+		<-msg.Context().Done()
+		close(cancelled)
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- n.Run() }()
+
+	if _, err := inw.Write([]byte(`{"dest":"n1", "body":{"type":"foo", "msg_id":1}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := inw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message context cancellation")
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages which were not received by a node have a background context.
+	var msg maelstrom.Message
+	if msg.Context() != context.Background() {
+		t.Fatal("expected background context")
+	}
+}
+
 // Ensure a duplicate handler causes a panic.
 func TestNode_Handle(t *testing.T) {
 	t.Run("ErrDuplicate", func(t *testing.T) {
@@ -154,6 +318,192 @@ func TestNode_Handle(t *testing.T) {
 	})
 }
 
+// Ensure unhandled messages are delegated to the default handler.
+func TestNode_HandleDefault(t *testing.T) {
+	t.Run("NotSupported", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n" +
+			`{"src":"n2", "body":{"type":"gossip"}}` + "\n" +
+			`{"src":"c1", "body":{"type":"echo", "msg_id":2}}` + "\n")
+		n.Stdout = &stdout
+		n.HandleDefault(maelstrom.NotSupportedHandler)
+		n.Handle("echo", func(msg maelstrom.Message) error {
+			return n.Reply(msg, maelstrom.MessageBody{Type: "echo_ok"})
+		})
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Replies may be written in any order.
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		sort.Strings(lines)
+		if got, want := strings.Join(lines, "\n"), ``+
+			`{"dest":"c1","body":{"code":10,"in_reply_to":1,"text":"message type \"foo\" not supported","type":"error"}}`+"\n"+
+			`{"dest":"c1","body":{"in_reply_to":2,"type":"echo_ok"}}`; got != want {
+			t.Fatalf("stdout=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("Ignore", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n")
+		n.Stdout = &stdout
+		n.HandleDefault(maelstrom.IgnoreHandler)
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		} else if got := stdout.String(); got != "" {
+			t.Fatalf("unexpected stdout: %s", got)
+		}
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n")
+		n.Stdout = &stdout
+		n.HandleDefault(func(msg maelstrom.Message) error {
+			return n.Reply(msg, maelstrom.MessageBody{Type: msg.Type() + "_ok"})
+		})
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		} else if got, want := stdout.String(), `{"dest":"c1","body":{"in_reply_to":1,"type":"foo_ok"}}`+"\n"; got != want {
+			t.Fatalf("stdout=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("ErrDuplicate", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.HandleDefault(maelstrom.IgnoreHandler)
+
+		var r any
+		func() {
+			defer func() { r = recover() }()
+			n.HandleDefault(maelstrom.IgnoreHandler)
+		}()
+		if got, want := r, "duplicate default message handler"; got != want {
+			t.Fatalf("recover=%s, want %s", got, want)
+		}
+	})
+}
+
+// Ensure panics in handlers are recovered and replied to with a Crash error.
+func TestNode_Panic(t *testing.T) {
+	t.Run("Handler", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n")
+		n.Stdout = &stdout
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			panic("marker")
+		})
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		var msg maelstrom.Message
+		var body maelstrom.MessageBody
+		if err := json.Unmarshal(stdout.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		} else if err := json.Unmarshal(msg.Body, &body); err != nil {
+			t.Fatal(err)
+		} else if got, want := body.Code, maelstrom.Crash; got != want {
+			t.Fatalf("code=%d, want %d", got, want)
+		} else if got, want := body.InReplyTo, 1; got != want {
+			t.Fatalf("in_reply_to=%d, want %d", got, want)
+		}
+
+		// The trace starts at the panicking function & is trimmed.
+		lines := strings.Split(body.Text, "\n")
+		if got, want := lines[0], "panic: marker"; got != want {
+			t.Fatalf("text[0]=%s, want %s", got, want)
+		} else if !strings.HasPrefix(lines[1], "github.com/jepsen-io/maelstrom/demo/go_test.TestNode_Panic.func1.1") {
+			t.Fatalf("unexpected first frame: %s", lines[1])
+		} else if got, want := len(lines), 11; got != want {
+			t.Fatalf("len(lines)=%d, want %d", got, want)
+		} else if got, want := n.Stats().Panics, 1; got != want {
+			t.Fatalf("Panics=%d, want %d", got, want)
+		}
+	})
+
+	t.Run("NoMsgID", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"n2", "body":{"type":"foo"}}` + "\n")
+		n.Stdout = &stdout
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			panic("marker")
+		})
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The message cannot be replied to so the error is only logged.
+		if got := stdout.String(); got != "" {
+			t.Fatalf("unexpected output: %s", got)
+		} else if got, want := n.Stats().Panics, 1; got != want {
+			t.Fatalf("Panics=%d, want %d", got, want)
+		}
+	})
+
+	t.Run("Callback", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			return n.RPC("n2", map[string]any{"type": "bar"}, func(msg maelstrom.Message) error {
+				panic("marker")
+			})
+		})
+		n.Handle("echo", func(msg maelstrom.Message) error {
+			return n.Reply(msg, maelstrom.MessageBody{Type: "echo_ok"})
+		})
+		initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+		if _, err := stdin.Write([]byte(`{"src":"c1","body":{"type":"foo","msg_id":2}}` + "\n")); err != nil {
+			t.Fatal(err)
+		} else if _, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if _, err := stdin.Write([]byte(`{"src":"n2","body":{"type":"bar_ok","in_reply_to":1}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		// The node continues to handle messages.
+		if _, err := stdin.Write([]byte(`{"src":"c1","body":{"type":"echo","msg_id":3}}` + "\n")); err != nil {
+			t.Fatal(err)
+		} else if line, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if got, want := line, `{"src":"n1","dest":"c1","body":{"in_reply_to":3,"type":"echo_ok"}}`+"\n"; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("ErrMaxPanics", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.MaxPanics = 2
+		n.Stdin = strings.NewReader(strings.Repeat(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}`+"\n", 2))
+		n.Stdout = io.Discard
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			panic("marker")
+		})
+		if err := n.Run(); err == nil || err.Error() != "too many panics (2), last: marker" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Go", func(t *testing.T) {
+		stdin, _ := io.Pipe()
+		n := maelstrom.NewNode()
+		n.Stdin = stdin
+		n.Stdout = io.Discard
+		n.Go(func(ctx context.Context) error {
+			panic("marker")
+		})
+		if err := n.Run(); err == nil || err.Error() != "panic in background worker: marker" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
 // Ensure node can handle a request/response RPC call.
 func TestNode_RPC(t *testing.T) {
 	t.Run("OK", func(t *testing.T) {
@@ -282,11 +632,46 @@ func TestNode_SyncRPC(t *testing.T) {
 			t.Fatalf("response=%s, want %s", got, want)
 		}
 
-		// Ensure the response was received.
+		// Ensure a timeout error was returned.
 		select {
 		case err := <-errorCh:
-			if err == nil || err.Error() != `context deadline exceeded` {
+			if err == nil || err.Error() != `RPCError(Timeout, "context deadline exceeded")` {
 				t.Fatalf("unexpected error: %s", err)
+			} else if got, want := maelstrom.ErrorCode(err), maelstrom.Timeout; got != want {
+				t.Fatalf("code=%d, want %d", got, want)
+			} else if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded error: %#v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for RPC response")
+		}
+
+		// Write a late response. The node should drop it and still shut down.
+		if _, err := stdin.Write([]byte(`{"src":"n2", "dest":"n1", "body":{"type":"foo_ok", "msg_id":2, "in_reply_to":1}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ErrContextCanceled", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errorCh := make(chan error)
+		go func() {
+			_, err := n.SyncRPC(ctx, "n2", map[string]any{"type": "foo"})
+			errorCh <- err
+		}()
+
+		if _, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+
+		select {
+		case err := <-errorCh:
+			if err != context.Canceled {
+				t.Fatalf("unexpected error: %#v", err)
 			}
 		case <-time.After(5 * time.Second):
 			t.Fatal("timeout waiting for RPC response")
@@ -0,0 +1,101 @@
+package maelstrom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DefaultOutputQueueSize is the default value of Node.OutputQueueSize.
+const DefaultOutputQueueSize = 1024
+
+// outputWriter writes encoded messages to STDOUT from a dedicated goroutine.
+// Writes are buffered and flushed once the queue is empty so that messages
+// sent in bursts are batched into fewer system calls.
+type outputWriter struct {
+	w     *bufio.Writer
+	queue chan []byte
+	done  chan struct{}
+	fail  func(error) // called on the first write error
+
+	mu  sync.Mutex
+	err error
+}
+
+// newOutputWriter returns a new writer for w and starts its goroutine.
+// Up to size messages can be queued before senders block.
+func newOutputWriter(w io.Writer, size int, fail func(error)) *outputWriter {
+	out := &outputWriter{
+		w:     bufio.NewWriter(w),
+		queue: make(chan []byte, size),
+		done:  make(chan struct{}),
+		fail:  fail,
+	}
+	go out.run()
+	return out
+}
+
+// run writes queued messages until the queue is closed.
+func (w *outputWriter) run() {
+	defer close(w.done)
+
+	for buf := range w.queue {
+		// Continue draining after an error so senders are not blocked.
+		if w.Err() != nil {
+			continue
+		}
+
+		_, err := w.w.Write(buf)
+		if err == nil && len(w.queue) == 0 {
+			err = w.w.Flush()
+		}
+		if err != nil {
+			w.setErr(fmt.Errorf("write stdout: %w", err))
+		}
+	}
+
+	if w.Err() == nil {
+		if err := w.w.Flush(); err != nil {
+			w.setErr(fmt.Errorf("write stdout: %w", err))
+		}
+	}
+}
+
+// enqueue adds an encoded message to the queue. Blocks while the queue is
+// full. Returns the first write error, if one has occurred.
+func (w *outputWriter) enqueue(buf []byte) error {
+	if err := w.Err(); err != nil {
+		return err
+	}
+	w.queue <- buf
+	return nil
+}
+
+// close flushes all queued messages & stops the writer goroutine. Must only
+// be called once no more messages will be enqueued.
+func (w *outputWriter) close() error {
+	close(w.queue)
+	<-w.done
+	return w.Err()
+}
+
+// Err returns the first write error, if any.
+func (w *outputWriter) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *outputWriter) setErr(err error) {
+	w.mu.Lock()
+	first := w.err == nil
+	if first {
+		w.err = err
+	}
+	w.mu.Unlock()
+
+	if first && w.fail != nil {
+		w.fail(err)
+	}
+}
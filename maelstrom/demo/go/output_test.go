@@ -0,0 +1,163 @@
+package maelstrom_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestNode_Output(t *testing.T) {
+	// Ensure messages sent in a burst are batched into fewer writes.
+	t.Run("Batch", func(t *testing.T) {
+		stdout := newBlockingWriter()
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n")
+		n.Stdout = stdout
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			for i := 0; i < 100; i++ {
+				if err := n.Send("c1", maelstrom.MessageBody{Type: "bar"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		// Release writes once all messages are queued.
+		go func() {
+			waitUntil(t, func() bool { return n.Stats().SentByType["bar"].Messages == 100 })
+			stdout.release()
+		}()
+
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := stdout.String(), strings.Repeat(`{"dest":"c1","body":{"type":"bar"}}`+"\n", 100); got != want {
+			t.Fatalf("stdout=%s, want %s", got, want)
+		} else if n := stdout.writes(); n > 10 {
+			t.Fatalf("unexpected write count: %d", n)
+		}
+	})
+
+	// Ensure senders block while the output queue is full.
+	t.Run("Backpressure", func(t *testing.T) {
+		stdout := newBlockingWriter()
+		n := maelstrom.NewNode()
+		n.OutputQueueSize = 1
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n")
+		n.Stdout = stdout
+
+		var sent int32
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			// Wait for the writer to block on the first message.
+			if err := n.Send("c1", maelstrom.MessageBody{Type: "bar"}); err != nil {
+				return err
+			}
+			waitUntil(t, func() bool { return stdout.pending() == 1 })
+
+			// The second message is queued & the third must wait.
+			for i := 0; i < 2; i++ {
+				if err := n.Send("c1", maelstrom.MessageBody{Type: "bar"}); err != nil {
+					return err
+				}
+				atomic.AddInt32(&sent, 1)
+			}
+			return nil
+		})
+
+		go func() {
+			waitUntil(t, func() bool { return atomic.LoadInt32(&sent) == 1 })
+			time.Sleep(50 * time.Millisecond)
+			if got, want := atomic.LoadInt32(&sent), int32(1); got != want {
+				t.Errorf("sent=%d, want %d", got, want)
+			}
+			stdout.release()
+		}()
+
+		if err := n.Run(); err != nil {
+			t.Fatal(err)
+		} else if got, want := stdout.String(), strings.Repeat(`{"dest":"c1","body":{"type":"bar"}}`+"\n", 3); got != want {
+			t.Fatalf("stdout=%s, want %s", got, want)
+		}
+	})
+
+	// Ensure a write error stops the node.
+	t.Run("ErrWrite", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.Stdin = strings.NewReader(`{"src":"c1", "body":{"type":"foo", "msg_id":1}}` + "\n")
+		n.Stdout = errWriter{errors.New("marker")}
+		n.Handle("foo", func(msg maelstrom.Message) error {
+			return n.Reply(msg, maelstrom.MessageBody{Type: "foo_ok"})
+		})
+		if err := n.Run(); err == nil || err.Error() != `write stdout: marker` {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	// Ensure messages sent outside of Run() are written immediately.
+	t.Run("Sync", func(t *testing.T) {
+		var stdout bytes.Buffer
+		n := maelstrom.NewNode()
+		n.Stdout = &stdout
+		if err := n.Send("n2", maelstrom.MessageBody{Type: "foo"}); err != nil {
+			t.Fatal(err)
+		} else if got, want := stdout.String(), `{"dest":"n2","body":{"type":"foo"}}`+"\n"; got != want {
+			t.Fatalf("stdout=%s, want %s", got, want)
+		}
+	})
+}
+
+// blockingWriter is a writer which blocks all writes until released.
+type blockingWriter struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	n     int
+	wait  int32 // number of blocked writes
+	once  sync.Once
+	ready chan struct{}
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{ready: make(chan struct{})}
+}
+
+func (w *blockingWriter) release() {
+	w.once.Do(func() { close(w.ready) })
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	atomic.AddInt32(&w.wait, 1)
+	<-w.ready
+	atomic.AddInt32(&w.wait, -1)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.n++
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func (w *blockingWriter) pending() int32 {
+	return atomic.LoadInt32(&w.wait)
+}
+
+func (w *blockingWriter) writes() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.n
+}
+
+// errWriter is a writer which always returns an error.
+type errWriter struct{ err error }
+
+func (w errWriter) Write(p []byte) (int, error) { return 0, w.err }
@@ -0,0 +1,65 @@
+package raft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// KV is a StateMachine implementing the "read", "write" & "cas" requests of
+// the lin-kv workload. Keys & values may be any JSON value and are compared
+// by their encoded form.
+type KV struct {
+	m map[string]json.RawMessage
+}
+
+// NewKV returns a new, empty instance of KV.
+func NewKV() *KV {
+	return &KV{m: make(map[string]json.RawMessage)}
+}
+
+// kvCommand represents the body of a lin-kv request.
+type kvCommand struct {
+	Type  string          `json:"type"`
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// Apply implements StateMachine.
+func (kv *KV) Apply(cmd json.RawMessage) (any, error) {
+	var c kvCommand
+	if err := json.Unmarshal(cmd, &c); err != nil {
+		return nil, maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+	}
+	key := string(c.Key)
+
+	switch c.Type {
+	case "read":
+		value, ok := kv.m[key]
+		if !ok {
+			return nil, maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "key does not exist")
+		}
+		return map[string]any{"type": "read_ok", "value": value}, nil
+
+	case "write":
+		kv.m[key] = c.Value
+		return map[string]any{"type": "write_ok"}, nil
+
+	case "cas":
+		value, ok := kv.m[key]
+		if !ok {
+			return nil, maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "key does not exist")
+		} else if !bytes.Equal(value, c.From) {
+			return nil, maelstrom.NewRPCError(maelstrom.PreconditionFailed, fmt.Sprintf("expected %s, but had %s", c.From, value))
+		}
+		kv.m[key] = c.To
+		return map[string]any{"type": "cas_ok"}, nil
+
+	default:
+		return nil, maelstrom.NewRPCError(maelstrom.NotSupported, fmt.Sprintf("unsupported command type: %q", c.Type))
+	}
+}
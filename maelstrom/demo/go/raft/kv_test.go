@@ -0,0 +1,44 @@
+package raft_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/raft"
+)
+
+func TestKV_Apply(t *testing.T) {
+	kv := raft.NewKV()
+	for _, tt := range []struct {
+		cmd  string
+		want string
+		code int
+	}{
+		{cmd: `{"type":"read","key":1}`, code: maelstrom.KeyDoesNotExist},
+		{cmd: `{"type":"cas","key":1,"from":1,"to":2}`, code: maelstrom.KeyDoesNotExist},
+		{cmd: `{"type":"write","key":1,"value":1}`, want: `{"type":"write_ok"}`},
+		{cmd: `{"type":"read","key":1}`, want: `{"type":"read_ok","value":1}`},
+		{cmd: `{"type":"cas","key":1,"from":2,"to":3}`, code: maelstrom.PreconditionFailed},
+		{cmd: `{"type":"cas","key":1,"from":1,"to":3}`, want: `{"type":"cas_ok"}`},
+		{cmd: `{"type":"read","key":1}`, want: `{"type":"read_ok","value":3}`},
+		{cmd: `{"type":"read","key":"1"}`, code: maelstrom.KeyDoesNotExist},
+		{cmd: `{"type":"txn"}`, code: maelstrom.NotSupported},
+	} {
+		body, err := kv.Apply(json.RawMessage(tt.cmd))
+		if tt.code != 0 {
+			if got, want := maelstrom.ErrorCode(err), tt.code; got != want {
+				t.Fatalf("%s: code=%d, want %d", tt.cmd, got, want)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("%s: %s", tt.cmd, err)
+		}
+
+		if buf, err := json.Marshal(body); err != nil {
+			t.Fatal(err)
+		} else if got, want := string(buf), tt.want; got != want {
+			t.Fatalf("%s: body=%s, want %s", tt.cmd, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package raft
+
+import "encoding/json"
+
+// Entry represents a single command in the replicated log.
+type Entry struct {
+	Term    int             `json:"term"`
+	Command json.RawMessage `json:"command,omitempty"`
+}
+
+// raftLog is a 1-indexed log of entries. Index 0 holds a sentinel entry with
+// a term of 0 so that the first real entry always has a valid predecessor.
+type raftLog struct {
+	entries []Entry
+}
+
+func newLog() *raftLog {
+	return &raftLog{entries: []Entry{{Term: 0}}}
+}
+
+// lastIndex returns the index of the last entry, or 0 if the log is empty.
+func (l *raftLog) lastIndex() int {
+	return len(l.entries) - 1
+}
+
+// lastTerm returns the term of the last entry.
+func (l *raftLog) lastTerm() int {
+	return l.entries[len(l.entries)-1].Term
+}
+
+// get returns the entry at index i. Returns false if there is no such entry.
+func (l *raftLog) get(i int) (Entry, bool) {
+	if i < 0 || i >= len(l.entries) {
+		return Entry{}, false
+	}
+	return l.entries[i], true
+}
+
+// append adds entries to the end of the log & returns the new last index.
+func (l *raftLog) append(entries ...Entry) int {
+	l.entries = append(l.entries, entries...)
+	return l.lastIndex()
+}
+
+// from returns a copy of the entries starting at index i.
+func (l *raftLog) from(i int) []Entry {
+	if i > l.lastIndex() {
+		return nil
+	}
+	return append([]Entry(nil), l.entries[i:]...)
+}
+
+// merge stores entries following index prev. Existing entries are kept unless
+// they conflict with a new entry, in which case they & all following entries
+// are discarded. Returns the index of the first discarded entry, or 0 if no
+// entries were discarded.
+func (l *raftLog) merge(prev int, entries []Entry) (truncated int) {
+	for i, e := range entries {
+		index := prev + 1 + i
+		if existing, ok := l.get(index); ok {
+			if existing.Term == e.Term {
+				continue
+			}
+			l.entries = l.entries[:index:index]
+			truncated = index
+		}
+		l.append(entries[i:]...)
+		break
+	}
+	return truncated
+}
@@ -0,0 +1,655 @@
+// Package raft implements the Raft consensus algorithm on top of a
+// maelstrom.Node, following the protocol of the Raft tutorial in
+// doc/06-raft. Commands submitted to the leader are appended to a replicated
+// log and applied to a StateMachine on every node once a majority of the
+// cluster has stored them.
+//
+// Raft state is kept in memory only, which is sufficient for Maelstrom's
+// network faults but not for process crashes.
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// StateMachine is the application state replicated by Raft. Commands are
+// applied in log order, exactly once per node, so Apply must be
+// deterministic. Apply is called with the Raft lock held and must not call
+// back into Raft.
+type StateMachine interface {
+	// Apply executes a committed command & returns the response body for
+	// the client, or an error such as a *maelstrom.RPCError.
+	Apply(cmd json.RawMessage) (any, error)
+}
+
+// State is the role of a node in the cluster.
+type State int
+
+const (
+	Follower State = iota
+	Candidate
+	Leader
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case Follower:
+		return "follower"
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	default:
+		return fmt.Sprintf("State<%d>", int(s))
+	}
+}
+
+// Config holds the timing parameters of a Raft node.
+type Config struct {
+	// Minimum time without hearing from a leader before a follower starts an
+	// election. Each timeout is randomized between ElectionTimeout and twice
+	// ElectionTimeout. A leader which receives no acknowledgements for
+	// ElectionTimeout steps down.
+	ElectionTimeout time.Duration
+
+	// Interval at which a leader sends append_entries to idle followers.
+	HeartbeatInterval time.Duration
+
+	// Minimum interval between replication rounds. Also the interval at
+	// which timeouts are checked.
+	ReplicationInterval time.Duration
+
+	// Maximum time to wait for a client request to be applied or for a
+	// forwarded request to be answered by the leader.
+	RequestTimeout time.Duration
+}
+
+// DefaultConfig returns the timing parameters of the Raft tutorial.
+func DefaultConfig() Config {
+	return Config{
+		ElectionTimeout:     2 * time.Second,
+		HeartbeatInterval:   time.Second,
+		ReplicationInterval: 50 * time.Millisecond,
+		RequestTimeout:      5 * time.Second,
+	}
+}
+
+// withDefaults returns c with zero fields set from DefaultConfig.
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.ElectionTimeout <= 0 {
+		c.ElectionTimeout = def.ElectionTimeout
+	}
+	if c.HeartbeatInterval <= 0 {
+		c.HeartbeatInterval = def.HeartbeatInterval
+	}
+	if c.ReplicationInterval <= 0 {
+		c.ReplicationInterval = def.ReplicationInterval
+	}
+	if c.RequestTimeout <= 0 {
+		c.RequestTimeout = def.RequestTimeout
+	}
+	return c
+}
+
+// NotLeaderError is returned by Submit on a node which is not the leader.
+type NotLeaderError struct {
+	// ID of the current leader, if known.
+	Leader string
+}
+
+// Error implements the error interface.
+func (e *NotLeaderError) Error() string {
+	if e.Leader == "" {
+		return "not a leader"
+	}
+	return fmt.Sprintf("not a leader; leader is %s", e.Leader)
+}
+
+// Raft represents a single member of a Raft cluster.
+type Raft struct {
+	node *maelstrom.Node
+	sm   StateMachine
+	cfg  Config
+
+	mu          sync.Mutex
+	state       State
+	term        int
+	votedFor    string
+	leader      string
+	log         *raftLog
+	commitIndex int
+	lastApplied int
+
+	// Leader state, reset on election.
+	nextIndex  map[string]int
+	matchIndex map[string]int
+
+	// Clients waiting for their commands to be applied, by log index.
+	pending map[int]*proposal
+
+	electionDeadline time.Time
+	stepDownDeadline time.Time
+	lastReplication  time.Time
+}
+
+// proposal is a command submitted on the leader which has not been applied.
+type proposal struct {
+	term int
+	ch   chan result
+}
+
+type result struct {
+	body any
+	err  error
+}
+
+// New returns a Raft member which runs on n & applies committed commands to
+// sm. Zero fields in cfg are set from DefaultConfig. Registers handlers for
+// the Raft protocol on n so it must be called before n.Run().
+func New(n *maelstrom.Node, sm StateMachine, cfg Config) *Raft {
+	r := &Raft{
+		node:    n,
+		sm:      sm,
+		cfg:     cfg.withDefaults(),
+		log:     newLog(),
+		pending: make(map[int]*proposal),
+	}
+
+	n.Handle("request_vote", r.handleRequestVote)
+	n.Handle("append_entries", r.handleAppendEntries)
+	n.OnInit(r.start)
+	return r
+}
+
+// State returns the current role of the node.
+func (r *Raft) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// Term returns the current term.
+func (r *Raft) Term() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.term
+}
+
+// Leader returns the ID of the current leader, or a blank string if unknown.
+func (r *Raft) Leader() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.leader
+}
+
+// CommitIndex returns the index of the last committed log entry.
+func (r *Raft) CommitIndex() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.commitIndex
+}
+
+// Submit appends cmd to the log & waits until it has been applied. Returns
+// the result of StateMachine.Apply. Returns a *NotLeaderError if this node is
+// not the leader.
+//
+// If ctx is done before the command is applied then a Timeout error is
+// returned and the command may still be applied later.
+func (r *Raft) Submit(ctx context.Context, cmd any) (any, error) {
+	buf, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.state != Leader {
+		leader := r.leader
+		r.mu.Unlock()
+		return nil, &NotLeaderError{Leader: leader}
+	}
+	index := r.log.append(Entry{Term: r.term, Command: buf})
+	p := &proposal{term: r.term, ch: make(chan result, 1)}
+	r.pending[index] = p
+	r.advanceCommitIndex() // commits immediately in a single-node cluster
+	r.mu.Unlock()
+
+	select {
+	case res := <-p.ch:
+		return res.body, res.err
+	case <-ctx.Done():
+		r.mu.Lock()
+		if r.pending[index] == p {
+			delete(r.pending, index)
+		}
+		r.mu.Unlock()
+		return nil, maelstrom.NewRPCError(maelstrom.Timeout, ctx.Err().Error())
+	}
+}
+
+// HandleClient registers a handler for client requests of the given type.
+// The request body is submitted as a command on the leader and the result of
+// StateMachine.Apply is sent as the reply. Followers forward requests to the
+// leader and relay its response. Must be called before n.Run().
+func (r *Raft) HandleClient(typ string) {
+	r.node.Handle(typ, r.handleClient)
+}
+
+func (r *Raft) handleClient(msg maelstrom.Message) error {
+	ctx, cancel := context.WithTimeout(msg.Context(), r.cfg.RequestTimeout)
+	defer cancel()
+
+	body, err := r.Submit(ctx, json.RawMessage(msg.Body))
+
+	var notLeader *NotLeaderError
+	if errors.As(err, &notLeader) {
+		if notLeader.Leader == "" || notLeader.Leader == r.node.ID() {
+			return maelstrom.NewRPCError(maelstrom.TemporarilyUnavailable, "not a leader")
+		}
+
+		resp, err := r.node.SyncRPC(ctx, notLeader.Leader, json.RawMessage(msg.Body))
+		if err != nil {
+			return err
+		}
+		return r.node.Reply(msg, json.RawMessage(resp.Body))
+	} else if err != nil {
+		return err
+	}
+	return r.node.Reply(msg, body)
+}
+
+// start begins checking timeouts once the node is initialized.
+func (r *Raft) start(ctx context.Context) error {
+	r.mu.Lock()
+	r.resetElectionDeadline()
+	r.mu.Unlock()
+
+	r.node.Every(r.cfg.ReplicationInterval, 0, r.tick)
+	return nil
+}
+
+// tick starts elections, steps down stale leaders & replicates the log.
+func (r *Raft) tick(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.node.Clock.Now()
+	if r.state == Leader && now.After(r.stepDownDeadline) {
+		log.Printf("Stepping down: no acknowledgements for term %d", r.term)
+		r.leader = ""
+		r.becomeFollower()
+	}
+
+	if now.After(r.electionDeadline) {
+		if r.state == Leader {
+			r.resetElectionDeadline()
+		} else {
+			r.becomeCandidate()
+		}
+	}
+
+	if r.state == Leader {
+		r.replicate(now)
+	}
+	return nil
+}
+
+// peers returns the IDs of the other nodes in the cluster.
+func (r *Raft) peers() []string {
+	var a []string
+	for _, id := range r.node.NodeIDs() {
+		if id != r.node.ID() {
+			a = append(a, id)
+		}
+	}
+	return a
+}
+
+// majority returns the number of nodes which form a majority of the cluster.
+func (r *Raft) majority() int {
+	return len(r.node.NodeIDs())/2 + 1
+}
+
+func (r *Raft) resetElectionDeadline() {
+	timeout := time.Duration(float64(r.cfg.ElectionTimeout) * (1 + rand.Float64()))
+	r.electionDeadline = r.node.Clock.Now().Add(timeout)
+}
+
+func (r *Raft) resetStepDownDeadline() {
+	r.stepDownDeadline = r.node.Clock.Now().Add(r.cfg.ElectionTimeout)
+}
+
+// maybeStepDown moves to term & becomes a follower if term is newer than the
+// current term.
+func (r *Raft) maybeStepDown(term int) {
+	if term > r.term {
+		log.Printf("Stepping down: received term %d, higher than %d", term, r.term)
+		r.term, r.votedFor, r.leader = term, "", ""
+		r.becomeFollower()
+	}
+}
+
+func (r *Raft) becomeFollower() {
+	r.state = Follower
+	r.nextIndex, r.matchIndex = nil, nil
+	r.resetElectionDeadline()
+}
+
+func (r *Raft) becomeCandidate() {
+	r.state = Candidate
+	r.term++
+	r.votedFor, r.leader = r.node.ID(), ""
+	r.resetElectionDeadline()
+	log.Printf("Became candidate for term %d", r.term)
+	r.requestVotes()
+}
+
+func (r *Raft) becomeLeader() {
+	r.state, r.leader = Leader, r.node.ID()
+	r.lastReplication = time.Time{}
+	r.nextIndex, r.matchIndex = make(map[string]int), make(map[string]int)
+	for _, peer := range r.peers() {
+		r.nextIndex[peer] = r.log.lastIndex() + 1
+		r.matchIndex[peer] = 0
+	}
+	r.resetStepDownDeadline()
+	log.Printf("Became leader for term %d", r.term)
+}
+
+// requestVoteBody represents the body of a "request_vote" message.
+type requestVoteBody struct {
+	maelstrom.MessageBody
+	Term         int    `json:"term"`
+	CandidateID  string `json:"candidate_id"`
+	LastLogIndex int    `json:"last_log_index"`
+	LastLogTerm  int    `json:"last_log_term"`
+}
+
+// requestVoteResBody represents the body of a "request_vote_res" message.
+type requestVoteResBody struct {
+	maelstrom.MessageBody
+	Term        int  `json:"term"`
+	VoteGranted bool `json:"vote_granted"`
+}
+
+// requestVotes asks every peer to vote for this node in the current term.
+func (r *Raft) requestVotes() {
+	term := r.term
+	votes := map[string]bool{r.node.ID(): true}
+	if len(votes) >= r.majority() {
+		r.becomeLeader()
+		return
+	}
+
+	req := requestVoteBody{
+		MessageBody:  maelstrom.MessageBody{Type: "request_vote"},
+		Term:         term,
+		CandidateID:  r.node.ID(),
+		LastLogIndex: r.log.lastIndex(),
+		LastLogTerm:  r.log.lastTerm(),
+	}
+	for _, peer := range r.peers() {
+		peer := peer
+		r.call(peer, req, func(msg maelstrom.Message) error {
+			var res requestVoteResBody
+			if err := json.Unmarshal(msg.Body, &res); err != nil {
+				return err
+			}
+
+			r.maybeStepDown(res.Term)
+			if r.state == Candidate && r.term == term && res.Term == term && res.VoteGranted {
+				votes[peer] = true
+				if len(votes) >= r.majority() {
+					r.becomeLeader()
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (r *Raft) handleRequestVote(msg maelstrom.Message) error {
+	var req requestVoteBody
+	if err := json.Unmarshal(msg.Body, &req); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.maybeStepDown(req.Term)
+
+	grant := false
+	switch {
+	case req.Term < r.term:
+	case r.votedFor != "" && r.votedFor != req.CandidateID:
+	case req.LastLogTerm < r.log.lastTerm():
+	case req.LastLogTerm == r.log.lastTerm() && req.LastLogIndex < r.log.lastIndex():
+	default:
+		grant = true
+		r.votedFor = req.CandidateID
+		r.resetElectionDeadline()
+	}
+
+	return r.node.Reply(msg, requestVoteResBody{
+		MessageBody: maelstrom.MessageBody{Type: "request_vote_res"},
+		Term:        r.term,
+		VoteGranted: grant,
+	})
+}
+
+// appendEntriesBody represents the body of an "append_entries" message.
+type appendEntriesBody struct {
+	maelstrom.MessageBody
+	Term         int     `json:"term"`
+	LeaderID     string  `json:"leader_id"`
+	PrevLogIndex int     `json:"prev_log_index"`
+	PrevLogTerm  int     `json:"prev_log_term"`
+	Entries      []Entry `json:"entries"`
+	LeaderCommit int     `json:"leader_commit"`
+}
+
+// appendEntriesResBody represents the body of an "append_entries_res"
+// message.
+type appendEntriesResBody struct {
+	maelstrom.MessageBody
+	Term    int  `json:"term"`
+	Success bool `json:"success"`
+}
+
+// replicate sends new entries to each follower, or empty heartbeats once
+// HeartbeatInterval has passed since the last replication round.
+func (r *Raft) replicate(now time.Time) {
+	elapsed := now.Sub(r.lastReplication)
+	if elapsed < r.cfg.ReplicationInterval {
+		return
+	}
+	heartbeat := elapsed >= r.cfg.HeartbeatInterval
+
+	sent := false
+	for _, peer := range r.peers() {
+		peer, term, next := peer, r.term, r.nextIndex[peer]
+		entries := r.log.from(next)
+		if len(entries) == 0 && !heartbeat {
+			continue
+		}
+
+		prev, _ := r.log.get(next - 1)
+		req := appendEntriesBody{
+			MessageBody:  maelstrom.MessageBody{Type: "append_entries"},
+			Term:         term,
+			LeaderID:     r.node.ID(),
+			PrevLogIndex: next - 1,
+			PrevLogTerm:  prev.Term,
+			Entries:      entries,
+			LeaderCommit: r.commitIndex,
+		}
+		r.call(peer, req, func(msg maelstrom.Message) error {
+			var res appendEntriesResBody
+			if err := json.Unmarshal(msg.Body, &res); err != nil {
+				return err
+			}
+
+			r.maybeStepDown(res.Term)
+			if r.state != Leader || r.term != term {
+				return nil
+			}
+			r.resetStepDownDeadline()
+
+			if res.Success {
+				if match := next - 1 + len(entries); match > r.matchIndex[peer] {
+					r.matchIndex[peer] = match
+				}
+				if r.nextIndex[peer] <= r.matchIndex[peer] {
+					r.nextIndex[peer] = r.matchIndex[peer] + 1
+				}
+				r.advanceCommitIndex()
+			} else if r.nextIndex[peer] == next && next > 1 {
+				r.nextIndex[peer] = next - 1
+			}
+			return nil
+		})
+		sent = true
+	}
+
+	if sent {
+		r.lastReplication = now
+	}
+}
+
+func (r *Raft) handleAppendEntries(msg maelstrom.Message) error {
+	var req appendEntriesBody
+	if err := json.Unmarshal(msg.Body, &req); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.maybeStepDown(req.Term)
+
+	res := appendEntriesResBody{
+		MessageBody: maelstrom.MessageBody{Type: "append_entries_res"},
+		Term:        r.term,
+	}
+	if req.Term < r.term {
+		return r.node.Reply(msg, res)
+	}
+
+	// The sender is the leader of our term.
+	r.leader = req.LeaderID
+	if r.state == Candidate {
+		r.becomeFollower()
+	}
+	r.resetElectionDeadline()
+
+	if prev, ok := r.log.get(req.PrevLogIndex); !ok || prev.Term != req.PrevLogTerm {
+		return r.node.Reply(msg, res)
+	}
+
+	if truncated := r.log.merge(req.PrevLogIndex, req.Entries); truncated > 0 {
+		r.discardPending(truncated)
+	}
+
+	// Entries are only known to match the leader's log up to the last one
+	// sent, which may be behind our commit index if this request is stale.
+	commit := req.LeaderCommit
+	if last := req.PrevLogIndex + len(req.Entries); commit > last {
+		commit = last
+	}
+	if commit > r.commitIndex {
+		r.commitIndex = commit
+		r.applyCommitted()
+	}
+
+	res.Success = true
+	return r.node.Reply(msg, res)
+}
+
+// call sends body to peer in the background & passes the response to fn
+// with the lock held. Requests which fail or are not answered within
+// ElectionTimeout are dropped, as Raft retries them on the next round.
+func (r *Raft) call(peer string, body any, fn maelstrom.HandlerFunc) {
+	r.node.Go(func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, r.cfg.ElectionTimeout)
+		defer cancel()
+
+		resp, err := r.node.SyncRPC(ctx, peer, body)
+		if err != nil {
+			return nil
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := fn(resp); err != nil {
+			log.Printf("Invalid response from %s: %s", peer, err)
+		}
+		return nil
+	})
+}
+
+// advanceCommitIndex commits the highest entry of the current term which is
+// stored on a majority of the cluster.
+func (r *Raft) advanceCommitIndex() {
+	matches := []int{r.log.lastIndex()}
+	for _, index := range r.matchIndex {
+		matches = append(matches, index)
+	}
+	sort.Ints(matches)
+
+	index := matches[len(matches)-r.majority()]
+	if e, ok := r.log.get(index); ok && index > r.commitIndex && e.Term == r.term {
+		r.commitIndex = index
+		r.applyCommitted()
+	}
+}
+
+// applyCommitted applies committed entries to the state machine & notifies
+// the clients waiting for them.
+func (r *Raft) applyCommitted() {
+	for r.lastApplied < r.commitIndex {
+		r.lastApplied++
+		e, _ := r.log.get(r.lastApplied)
+		body, err := r.sm.Apply(e.Command)
+
+		p := r.pending[r.lastApplied]
+		if p == nil {
+			continue
+		}
+		delete(r.pending, r.lastApplied)
+
+		if p.term == e.Term {
+			p.ch <- result{body: body, err: err}
+		} else {
+			p.ch <- result{err: errOverwritten()}
+		}
+	}
+}
+
+// discardPending fails the clients waiting for entries at index i or later,
+// which have been replaced by entries from another leader.
+func (r *Raft) discardPending(i int) {
+	for index, p := range r.pending {
+		if index >= i {
+			delete(r.pending, index)
+			p.ch <- result{err: errOverwritten()}
+		}
+	}
+}
+
+// errOverwritten returns the error for a command which was removed from the
+// log before it was committed. The command was definitely not applied.
+func errOverwritten() error {
+	return maelstrom.NewRPCError(maelstrom.TemporarilyUnavailable, "entry overwritten by a new leader")
+}
@@ -0,0 +1,295 @@
+package raft_test
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+	"github.com/jepsen-io/maelstrom/demo/go/raft"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+// Ensure a single leader is elected & followers learn about it.
+func TestRaft_Election(t *testing.T) {
+	c := newCluster(t, "n1", "n2", "n3")
+	c.waitAgree(t)
+
+	var n int
+	for _, r := range c.rafts {
+		if r.State() == raft.Leader {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Fatalf("leaders=%d, want 1", n)
+	}
+}
+
+// Ensure requests sent to followers are forwarded to the leader and that
+// committed entries are applied on every node.
+func TestRaft_Forward(t *testing.T) {
+	c := newCluster(t, "n1", "n2", "n3")
+	leader := c.waitAgree(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := c.net.Client()
+	for i, id := range c.ids {
+		if c.rafts[i] == leader {
+			continue
+		}
+		if err := workload.KVWrite(ctx, client, id, 1, i); err != nil {
+			t.Fatal(err)
+		}
+		for _, dest := range c.ids {
+			if v, err := workload.KVRead(ctx, client, dest, 1); err != nil {
+				t.Fatal(err)
+			} else if v != i {
+				t.Fatalf("read %s=%d, want %d", dest, v, i)
+			}
+		}
+	}
+
+	// Followers learn the commit index on the next heartbeat.
+	for _, r := range c.rafts {
+		r := r
+		waitUntil(t, func() bool { return r.CommitIndex() == leader.CommitIndex() })
+	}
+}
+
+// Ensure state machine errors are returned to the client.
+func TestRaft_ApplyError(t *testing.T) {
+	c := newCluster(t, "n1", "n2", "n3")
+	c.waitAgree(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := c.net.Client()
+	if _, err := workload.KVRead(ctx, client, "n1", 1); maelstrom.ErrorCode(err) != maelstrom.KeyDoesNotExist {
+		t.Fatalf("unexpected error: %v", err)
+	} else if err := workload.KVWrite(ctx, client, "n2", 1, 2); err != nil {
+		t.Fatal(err)
+	} else if err := workload.KVCAS(ctx, client, "n3", 1, 3, 4); maelstrom.ErrorCode(err) != maelstrom.PreconditionFailed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a new leader is elected when the leader is partitioned away and
+// that the old leader steps down & catches up once the partition heals.
+func TestRaft_Failover(t *testing.T) {
+	c := newCluster(t, "n1", "n2", "n3")
+	old := c.waitLeader(t, nil)
+	oldID, oldTerm := old.Leader(), old.Term()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := c.net.Client()
+	var others []string
+	for _, id := range c.ids {
+		if id != oldID {
+			others = append(others, id)
+		}
+	}
+	c.net.Partition([]string{oldID}, append(others, client.ID()))
+
+	leader := c.waitLeader(t, old)
+	if leader.Term() <= oldTerm {
+		t.Fatalf("term=%d, want greater than %d", leader.Term(), oldTerm)
+	}
+	waitUntil(t, func() bool { return old.State() != raft.Leader })
+	for _, r := range c.rafts {
+		if r := r; r != old {
+			waitUntil(t, func() bool { return r.Leader() == leader.Leader() })
+		}
+	}
+
+	if err := workload.KVWrite(ctx, client, others[0], 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	c.net.Heal()
+	waitUntil(t, func() bool { return old.CommitIndex() == leader.CommitIndex() })
+	if v, err := workload.KVRead(ctx, client, oldID, 1); err != nil {
+		t.Fatal(err)
+	} else if v != 1 {
+		t.Fatalf("read=%d, want 1", v)
+	}
+}
+
+// Ensure a single node commits entries on its own.
+func TestRaft_SingleNode(t *testing.T) {
+	c := newCluster(t, "n1")
+	c.waitLeader(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := c.net.Client()
+	if err := workload.KVWrite(ctx, client, "n1", 1, 2); err != nil {
+		t.Fatal(err)
+	} else if v, err := workload.KVRead(ctx, client, "n1", 1); err != nil {
+		t.Fatal(err)
+	} else if v != 2 {
+		t.Fatalf("read=%d, want 2", v)
+	}
+}
+
+// Ensure a history recorded against a Raft cluster while its leader is
+// repeatedly partitioned away is linearizable.
+func TestRaft_LinKV(t *testing.T) {
+	c := newCluster(t, "n1", "n2", "n3", "n4", "n5")
+	c.waitAgree(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	r := checker.NewRecorder()
+	var wg sync.WaitGroup
+	var clients []string
+	for process := 0; process < 5; process++ {
+		process, client, rand := process, c.net.Client(), rand.New(rand.NewSource(int64(process)))
+		clients = append(clients, client.ID())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 30; i++ {
+				dest, key := c.ids[rand.Intn(len(c.ids))], rand.Intn(2)
+
+				ctx, cancel := context.WithTimeout(ctx, time.Second)
+				switch rand.Intn(3) {
+				case 0:
+					r.Do(process, "read", checker.KVOp{Key: key}, func() (any, error) {
+						v, err := workload.KVRead(ctx, client, dest, key)
+						return checker.KVOp{Key: key, Value: v}, err
+					})
+				case 1:
+					op := checker.KVOp{Key: key, Value: rand.Intn(5)}
+					r.Do(process, "write", op, func() (any, error) {
+						return op, workload.KVWrite(ctx, client, dest, op.Key, op.Value)
+					})
+				case 2:
+					op := checker.KVOp{Key: key, From: rand.Intn(5), To: rand.Intn(5)}
+					r.Do(process, "cas", op, func() (any, error) {
+						return op, workload.KVCAS(ctx, client, dest, op.Key, op.From, op.To)
+					})
+				}
+				cancel()
+			}
+		}()
+	}
+
+	// Isolate the current leader a few times while clients are running.
+	for i := 0; i < 3; i++ {
+		time.Sleep(300 * time.Millisecond)
+		leader := c.waitLeader(t, nil).Leader()
+
+		var majority []string
+		for _, id := range c.ids {
+			if id != leader {
+				majority = append(majority, id)
+			}
+		}
+		c.net.Partition([]string{leader}, append(majority, clients...))
+		time.Sleep(500 * time.Millisecond)
+		c.net.Heal()
+	}
+	wg.Wait()
+
+	h := r.History()
+	if got, want := len(h), 300; got != want {
+		t.Fatalf("len=%d, want %d", got, want)
+	}
+	if res := checker.CheckLinearizable(h); !res.Valid {
+		t.Fatal(res)
+	}
+}
+
+// cluster is a set of Raft nodes replicating a KV on a simulated network.
+type cluster struct {
+	net   *simnet.Network
+	ids   []string
+	rafts []*raft.Raft
+}
+
+// newCluster starts a cluster of nodes with the given IDs, using short
+// timeouts. The cluster is closed when the test completes.
+func newCluster(tb testing.TB, ids ...string) *cluster {
+	tb.Helper()
+
+	c := &cluster{net: simnet.NewNetwork(), ids: ids}
+	for _, id := range ids {
+		n := maelstrom.NewNode()
+		r := raft.New(n, raft.NewKV(), raft.Config{
+			ElectionTimeout:     150 * time.Millisecond,
+			HeartbeatInterval:   50 * time.Millisecond,
+			ReplicationInterval: 10 * time.Millisecond,
+			RequestTimeout:      time.Second,
+		})
+		for _, typ := range []string{"read", "write", "cas"} {
+			r.HandleClient(typ)
+		}
+		c.net.AddNode(id, n)
+		c.rafts = append(c.rafts, r)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.net.Start(ctx); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if err := c.net.Close(); err != nil {
+			tb.Error(err)
+		}
+	})
+	return c
+}
+
+// waitLeader waits until a node other than except is the leader & returns it.
+func (c *cluster) waitLeader(tb testing.TB, except *raft.Raft) *raft.Raft {
+	tb.Helper()
+
+	var leader *raft.Raft
+	waitUntil(tb, func() bool {
+		for _, r := range c.rafts {
+			if r != except && r.State() == raft.Leader {
+				leader = r
+				return true
+			}
+		}
+		return false
+	})
+	return leader
+}
+
+// waitAgree waits until every node knows the same leader & returns it.
+func (c *cluster) waitAgree(tb testing.TB) *raft.Raft {
+	tb.Helper()
+
+	leader := c.waitLeader(tb, nil)
+	for _, r := range c.rafts {
+		r := r
+		waitUntil(tb, func() bool { return r.Leader() == leader.Leader() })
+	}
+	return leader
+}
+
+// waitUntil polls fn until it returns true or fails the test after 10s.
+func waitUntil(tb testing.TB, fn func() bool) {
+	tb.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			tb.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,132 @@
+package maelstrom
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultRetryableCodes are the error codes retried by a RetryPolicy with no
+// RetryableCodes specified.
+var DefaultRetryableCodes = []int{Timeout, TemporarilyUnavailable}
+
+// DefaultInitialBackoff is the delay before the first retry of a RetryPolicy
+// with no InitialBackoff specified.
+const DefaultInitialBackoff = 50 * time.Millisecond
+
+// RetryPolicy configures how RetryRPC retries failed requests.
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first one. If zero, requests
+	// are retried until the context is done.
+	MaxAttempts int
+
+	// Delay before the first retry. Each following delay is multiplied by
+	// Multiplier, up to MaxBackoff. Defaults to DefaultInitialBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Fraction of each delay, from 0 to 1, which is randomized. A jitter of
+	// 0.2 produces delays between 80% and 120% of the computed backoff.
+	Jitter float64
+
+	// Optional. Timeout for each individual attempt.
+	AttemptTimeout time.Duration
+
+	// Error codes which are retried. Defaults to DefaultRetryableCodes.
+	RetryableCodes []int
+}
+
+// DefaultRetryPolicy returns a policy suitable for most requests to peers
+// & services: five attempts with exponential backoff starting at 50ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		AttemptTimeout: time.Second,
+	}
+}
+
+// Retryable returns true if err has an error code which should be retried.
+func (p RetryPolicy) Retryable(err error) bool {
+	codes := p.RetryableCodes
+	if codes == nil {
+		codes = DefaultRetryableCodes
+	}
+
+	code := ErrorCode(err)
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry, starting at 1, without
+// jitter applied.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	d := float64(p.InitialBackoff)
+	if d <= 0 {
+		d = float64(DefaultInitialBackoff)
+	}
+	mult := p.Multiplier
+	if mult <= 0 {
+		mult = 1
+	}
+	for i := 1; i < retry; i++ {
+		d *= mult
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(d)
+}
+
+// jittered applies the policy's jitter to d.
+func (p RetryPolicy) jittered(d time.Duration) time.Duration {
+	return jittered(d, p.Jitter)
+}
+
+// RetryRPC sends a synchronous RPC request, retrying failed attempts
+// according to policy. Each attempt is sent as a new message. Returns the
+// first successful response or the error from the last attempt.
+//
+// Only errors with a code listed in the policy's retryable codes are retried.
+// Returns early if ctx is done while waiting for a response or a backoff.
+func (n *Node) RetryRPC(ctx context.Context, dest string, body any, policy RetryPolicy) (Message, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := n.syncRPCAttempt(ctx, dest, body, policy.AttemptTimeout)
+		if err == nil {
+			return resp, nil
+		} else if ctx.Err() != nil || !policy.Retryable(err) {
+			return resp, err
+		} else if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return resp, err
+		}
+
+		// Wait before the next attempt.
+		timer := n.Clock.NewTimer(policy.jittered(policy.Backoff(attempt)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C():
+		}
+	}
+}
+
+// syncRPCAttempt executes a single SyncRPC attempt with an optional timeout.
+func (n *Node) syncRPCAttempt(ctx context.Context, dest string, body any, timeout time.Duration) (Message, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return n.SyncRPC(ctx, dest, body)
+}
@@ -0,0 +1,166 @@
+package maelstrom_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := maelstrom.RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2,
+	}
+	for _, tt := range []struct {
+		retry int
+		d     time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{100, 50 * time.Millisecond},
+	} {
+		if got, want := p.Backoff(tt.retry), tt.d; got != want {
+			t.Errorf("retry %d=%s, want %s", tt.retry, got, want)
+		}
+	}
+
+	// Ensure an unset initial backoff does not retry immediately.
+	if got, want := (maelstrom.RetryPolicy{}).Backoff(1), maelstrom.DefaultInitialBackoff; got != want {
+		t.Fatalf("backoff=%s, want %s", got, want)
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	p := maelstrom.DefaultRetryPolicy()
+	if !p.Retryable(maelstrom.NewRPCError(maelstrom.TemporarilyUnavailable, "")) {
+		t.Fatal("expected TemporarilyUnavailable to be retryable")
+	} else if !p.Retryable(fmt.Errorf("wrapped: %w", maelstrom.NewRPCError(maelstrom.Timeout, ""))) {
+		t.Fatal("expected wrapped Timeout to be retryable")
+	} else if p.Retryable(maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "")) {
+		t.Fatal("expected KeyDoesNotExist to not be retryable")
+	} else if p.Retryable(fmt.Errorf("marshal error")) {
+		t.Fatal("expected non-RPC error to not be retryable")
+	}
+
+	p.RetryableCodes = []int{maelstrom.KeyDoesNotExist}
+	if !p.Retryable(maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "")) {
+		t.Fatal("expected KeyDoesNotExist to be retryable")
+	}
+}
+
+func TestNode_RetryRPC(t *testing.T) {
+	policy := maelstrom.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+		respCh := make(chan maelstrom.Message)
+		errorCh := make(chan error)
+		go func() {
+			resp, err := n.RetryRPC(context.Background(), "n2", map[string]any{"type": "foo"}, policy)
+			if err != nil {
+				errorCh <- err
+			} else {
+				respCh <- resp
+			}
+		}()
+
+		// Fail the first attempt, then succeed on the second.
+		if line, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if got, want := line, `{"src":"n1","dest":"n2","body":{"msg_id":1,"type":"foo"}}`+"\n"; got != want {
+			t.Fatalf("request=%s, want %s", got, want)
+		}
+		if _, err := stdin.Write([]byte(`{"src":"n2", "dest":"n1", "body":{"type":"error", "in_reply_to":1, "code":11}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		if line, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if got, want := line, `{"src":"n1","dest":"n2","body":{"msg_id":2,"type":"foo"}}`+"\n"; got != want {
+			t.Fatalf("request=%s, want %s", got, want)
+		}
+		if _, err := stdin.Write([]byte(`{"src":"n2", "dest":"n1", "body":{"type":"foo_ok", "in_reply_to":2}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case msg := <-respCh:
+			if got, want := msg.Type(), "foo_ok"; got != want {
+				t.Fatalf("type=%s, want %s", got, want)
+			}
+		case err := <-errorCh:
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for RPC response")
+		}
+	})
+
+	t.Run("ErrNotRetryable", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+		errorCh := make(chan error)
+		go func() {
+			_, err := n.RetryRPC(context.Background(), "n2", map[string]any{"type": "foo"}, policy)
+			errorCh <- err
+		}()
+
+		if _, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stdin.Write([]byte(`{"src":"n2", "dest":"n1", "body":{"type":"error", "in_reply_to":1, "code":20}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case err := <-errorCh:
+			if got, want := maelstrom.ErrorCode(err), maelstrom.KeyDoesNotExist; got != want {
+				t.Fatalf("code=%d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for RPC response")
+		}
+	})
+
+	t.Run("ErrMaxAttempts", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+		errorCh := make(chan error)
+		go func() {
+			p := policy
+			p.AttemptTimeout = 10 * time.Millisecond
+			_, err := n.RetryRPC(context.Background(), "n2", map[string]any{"type": "foo"}, p)
+			errorCh <- err
+		}()
+
+		// Never respond to any of the attempts.
+		for i := 0; i < policy.MaxAttempts; i++ {
+			if _, err := stdout.ReadString('\n'); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		select {
+		case err := <-errorCh:
+			if got, want := maelstrom.ErrorCode(err), maelstrom.Timeout; got != want {
+				t.Fatalf("code=%d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for RPC response")
+		}
+	})
+}
@@ -61,6 +61,9 @@ func ErrorCode(err error) int {
 type RPCError struct {
 	Code int
 	Text string
+
+	// Underlying error which caused a locally-generated RPC error, if any.
+	err error
 }
 
 // NewRPCError returns a new instance of RPCError.
@@ -71,11 +74,26 @@ func NewRPCError(code int, text string) *RPCError {
 	}
 }
 
+// newTimeoutError returns a Timeout error wrapping a context error.
+func newTimeoutError(err error) *RPCError {
+	return &RPCError{
+		Code: Timeout,
+		Text: err.Error(),
+		err:  err,
+	}
+}
+
 // Error returns a string-formatted error message.
 func (e *RPCError) Error() string {
 	return fmt.Sprintf("RPCError(%s, %q)", ErrorCodeText(e.Code), e.Text)
 }
 
+// Unwrap returns the underlying error, if any. This allows timeouts to be
+// matched with errors.Is(err, context.DeadlineExceeded).
+func (e *RPCError) Unwrap() error {
+	return e.err
+}
+
 // MarshalJSON marshals the error into JSON format.
 func (e *RPCError) MarshalJSON() ([]byte, error) {
 	return json.Marshal(rpcErrorJSON{
@@ -85,9 +103,12 @@ func (e *RPCError) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// rpcErrorJSON is a struct for marshaling an RPCError to JSON.
+// rpcErrorJSON is a struct for marshaling an RPCError to JSON. Fields are
+// ordered by key so that error replies, with "in_reply_to" inserted, are
+// encoded the same as when bodies were marshaled from a map. The code is
+// always encoded as Timeout is zero.
 type rpcErrorJSON struct {
-	Type string `json:"type,omitempty"`
-	Code int    `json:"code,omitempty"`
+	Code int    `json:"code"`
 	Text string `json:"text,omitempty"`
+	Type string `json:"type,omitempty"`
 }
@@ -1,6 +1,7 @@
 package maelstrom_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -47,3 +48,30 @@ func TestRPCError_ErrorCode(t *testing.T) {
 		t.Fatalf("error=%d, want %d", maelstrom.ErrorCode(err), maelstrom.Crash)
 	}
 }
+
+func TestRPCError_MarshalJSON(t *testing.T) {
+	// Timeout has a code of zero, which must still be encoded.
+	if buf, err := json.Marshal(maelstrom.NewRPCError(maelstrom.Timeout, "foo")); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), `{"code":0,"text":"foo","type":"error"}`; got != want {
+		t.Fatalf("json=%s, want %s", got, want)
+	}
+}
+
+func TestMessage_RPCError(t *testing.T) {
+	for _, tt := range []struct {
+		body string
+		err  error
+	}{
+		{`{"type":"read_ok","value":1}`, nil},
+		{`{"type":"error","code":0,"text":"foo"}`, maelstrom.NewRPCError(maelstrom.Timeout, "foo")},
+		{`{"type":"error","code":20,"text":"foo"}`, maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "foo")},
+	} {
+		msg := maelstrom.Message{Body: json.RawMessage(tt.body)}
+		if err := msg.RPCError(); tt.err == nil && err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.body, err)
+		} else if tt.err != nil && (err == nil || err.Error() != tt.err.Error()) {
+			t.Errorf("%s: error=%v, want %s", tt.body, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// LinKV is a linearizable key/value store. Every request is applied to a
+// single copy of the state, in the order received.
+type LinKV struct {
+	mu    sync.Mutex
+	state kvState
+}
+
+// NewLinKV returns a new, empty instance of LinKV.
+func NewLinKV() *LinKV {
+	return &LinKV{}
+}
+
+// Handle processes a read, write or cas request.
+func (s *LinKV) Handle(msg maelstrom.Message) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, resp, _ := s.state.handle(msg)
+	s.state = state
+	return resp
+}
+
+// DefaultSeqKVHistory is the number of past states retained by SeqKV.
+const DefaultSeqKVHistory = 32
+
+// SeqKV is a sequentially consistent key/value store. Requests which do not
+// change the store may observe any of the recent states of the store, as long
+// as each client observes a monotonic sequence of states. Requests which
+// change the store are always applied to the most recent state.
+type SeqKV struct {
+	mu        sync.Mutex
+	rand      *rand.Rand
+	states    []kvState      // recent states, oldest first
+	lastIndex int            // index of the newest state
+	clients   map[string]int // client to last observed index
+}
+
+// NewSeqKV returns a new, empty instance of SeqKV. Stale reads are chosen
+// using a random source seeded with seed.
+func NewSeqKV(seed int64) *SeqKV {
+	return &SeqKV{
+		rand:    newRand(seed),
+		states:  []kvState{{}},
+		clients: make(map[string]int),
+	}
+}
+
+// Handle processes a read, write or cas request.
+func (s *SeqKV) Handle(msg maelstrom.Message) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Pick a state between the client's last observed state & the newest one.
+	oldest := s.lastIndex - len(s.states) + 1
+	lo := s.clients[msg.Src]
+	if lo < oldest {
+		lo = oldest
+	}
+	index := lo + s.rand.Intn(s.lastIndex-lo+1)
+
+	// Speculatively execute against the older state. If the request did not
+	// change the state then the total order is preserved.
+	if _, resp, changed := s.states[index-oldest].handle(msg); !changed {
+		s.clients[msg.Src] = index
+		return resp
+	}
+
+	// Otherwise execute against the newest state & append it.
+	state, resp, _ := s.states[len(s.states)-1].handle(msg)
+	s.states = append(s.states, state)
+	if len(s.states) > DefaultSeqKVHistory {
+		s.states = s.states[1:]
+	}
+	s.lastIndex++
+	s.clients[msg.Src] = s.lastIndex
+	return resp
+}
+
+// DefaultLWWKVReplicas is the number of replicas simulated by LWWKV.
+const DefaultLWWKVReplicas = 2
+
+// LWWKV is an eventually consistent, last-write-wins key/value store. It
+// simulates several independent replicas which each handle requests on their
+// own and occasionally merge their states, preferring values with higher
+// Lamport timestamps. Concurrent updates on different replicas may be lost.
+type LWWKV struct {
+	mu       sync.Mutex
+	rand     *rand.Rand
+	replicas []lwwReplica
+}
+
+// NewLWWKV returns a new, empty instance of LWWKV. Replicas & merges are
+// chosen using a random source seeded with seed.
+func NewLWWKV(seed int64) *LWWKV {
+	return &LWWKV{
+		rand:     newRand(seed),
+		replicas: make([]lwwReplica, DefaultLWWKVReplicas),
+	}
+}
+
+// Handle processes a read, write or cas request.
+func (s *LWWKV) Handle(msg maelstrom.Message) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Merge one random replica into another.
+	src, dst := s.rand.Intn(len(s.replicas)), s.rand.Intn(len(s.replicas))
+	s.replicas[dst] = s.replicas[dst].merge(s.replicas[src])
+
+	// Apply the request to yet another random replica.
+	i := s.rand.Intn(len(s.replicas))
+	replica, resp := s.replicas[i].handle(msg)
+	s.replicas[i] = replica
+	return resp
+}
+
+// kvState is an immutable key/value map, indexed by the canonical encoding of
+// each key. Updates return a modified copy.
+type kvState map[string]json.RawMessage
+
+// handle applies a request to the state. Returns the new state, the response
+// body & whether the state was changed.
+func (s kvState) handle(msg maelstrom.Message) (kvState, any, bool) {
+	req, err := parseKVRequest(msg)
+	if err != nil {
+		return s, errorResponse(req.MessageBody, err), false
+	}
+
+	switch req.Type {
+	case "read":
+		v, ok := s[req.key]
+		if !ok {
+			return s, errorResponse(req.MessageBody, errKeyDoesNotExist()), false
+		}
+		return s, kvReadOKBody(req, v), false
+
+	case "write":
+		return s.set(req.key, req.Value), okBody(req, "write_ok"), !jsonEqual(s[req.key], req.Value)
+
+	case "cas":
+		v, ok := s[req.key]
+		if !ok && !req.CreateIfNotExists {
+			return s, errorResponse(req.MessageBody, errKeyDoesNotExist()), false
+		} else if ok && !jsonEqual(v, req.From) {
+			return s, errorResponse(req.MessageBody, errPreconditionFailed(v, req.From)), false
+		}
+		return s.set(req.key, req.To), okBody(req, "cas_ok"), !ok || !jsonEqual(v, req.To)
+
+	default:
+		return s, errorResponse(req.MessageBody, notSupported(req.Type)), false
+	}
+}
+
+// set returns a copy of the state with key set to value.
+func (s kvState) set(key string, value json.RawMessage) kvState {
+	other := make(kvState, len(s)+1)
+	for k, v := range s {
+		other[k] = v
+	}
+	other[key] = value
+	return other
+}
+
+// lwwReplica is a single replica of an LWWKV store.
+type lwwReplica struct {
+	clock  int
+	values map[string]lwwValue // by canonical key encoding
+}
+
+// lwwValue is a value stored in an lwwReplica along with its timestamp.
+type lwwValue struct {
+	ts    int
+	value json.RawMessage
+}
+
+// handle applies a request to the replica. Returns the new replica & the
+// response body.
+func (r lwwReplica) handle(msg maelstrom.Message) (lwwReplica, any) {
+	req, err := parseKVRequest(msg)
+	if err != nil {
+		return r, errorResponse(req.MessageBody, err)
+	}
+
+	switch req.Type {
+	case "read":
+		v, ok := r.values[req.key]
+		if !ok {
+			return r, errorResponse(req.MessageBody, errKeyDoesNotExist())
+		}
+		return r, kvReadOKBody(req, v.value)
+
+	case "write":
+		return r.set(req.key, req.Value), okBody(req, "write_ok")
+
+	case "cas":
+		// Like Maelstrom's lww-kv, create_if_not_exists is not supported.
+		v, ok := r.values[req.key]
+		if !ok {
+			return r, errorResponse(req.MessageBody, errKeyDoesNotExist())
+		} else if !jsonEqual(v.value, req.From) {
+			return r, errorResponse(req.MessageBody, errPreconditionFailed(v.value, req.From))
+		}
+		return r.set(req.key, req.To), okBody(req, "cas_ok")
+
+	default:
+		return r, errorResponse(req.MessageBody, notSupported(req.Type))
+	}
+}
+
+// set returns a copy of the replica with key set to value at the next timestamp.
+func (r lwwReplica) set(key string, value json.RawMessage) lwwReplica {
+	other := lwwReplica{
+		clock:  r.clock + 1,
+		values: make(map[string]lwwValue, len(r.values)+1),
+	}
+	for k, v := range r.values {
+		other.values[k] = v
+	}
+	other.values[key] = lwwValue{ts: r.clock, value: value}
+	return other
+}
+
+// merge returns a copy of the replica merged with other. Values with higher
+// timestamps win; ties keep the value of the receiving replica.
+func (r lwwReplica) merge(other lwwReplica) lwwReplica {
+	merged := lwwReplica{
+		clock:  r.clock,
+		values: make(map[string]lwwValue, len(r.values)),
+	}
+	if other.clock > merged.clock {
+		merged.clock = other.clock
+	}
+	for k, v := range r.values {
+		merged.values[k] = v
+	}
+	for k, v := range other.values {
+		if cur, ok := merged.values[k]; !ok || v.ts > cur.ts {
+			merged.values[k] = v
+		}
+	}
+	return merged
+}
+
+// kvRequestBody represents the body of any KV request.
+type kvRequestBody struct {
+	maelstrom.MessageBody
+	Key               json.RawMessage `json:"key"`
+	Value             json.RawMessage `json:"value"`
+	From              json.RawMessage `json:"from"`
+	To                json.RawMessage `json:"to"`
+	CreateIfNotExists bool            `json:"create_if_not_exists,omitempty"`
+
+	key string // canonical encoding of Key
+}
+
+// kvReadOKMessageBody represents the response body for a "read" request.
+type kvReadOKMessageBody struct {
+	maelstrom.MessageBody
+	Value json.RawMessage `json:"value"`
+}
+
+func parseKVRequest(msg maelstrom.Message) (req kvRequestBody, err error) {
+	if err := json.Unmarshal(msg.Body, &req); err != nil {
+		return req, maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+	} else if req.key, err = canonicalKey(req.Key); err != nil {
+		return req, maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+	}
+	return req, nil
+}
+
+// canonicalKey returns the encoding of key with insignificant whitespace
+// removed & object fields sorted so equal keys share the same encoding.
+// Numbers keep their original encoding. A missing key is encoded as null.
+func canonicalKey(key json.RawMessage) (string, error) {
+	if key == nil {
+		return "null", nil
+	}
+
+	var v any
+	if err := decodeJSON(key, &v); err != nil {
+		return "", err
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func kvReadOKBody(req kvRequestBody, value json.RawMessage) kvReadOKMessageBody {
+	return kvReadOKMessageBody{
+		MessageBody: maelstrom.MessageBody{Type: "read_ok", InReplyTo: req.MsgID},
+		Value:       value,
+	}
+}
+
+func okBody(req kvRequestBody, typ string) maelstrom.MessageBody {
+	return maelstrom.MessageBody{Type: typ, InReplyTo: req.MsgID}
+}
+
+func errKeyDoesNotExist() error {
+	return maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "key does not exist")
+}
+
+func errPreconditionFailed(current, from json.RawMessage) error {
+	return maelstrom.NewRPCError(maelstrom.PreconditionFailed, fmt.Sprintf("current value %s is not %s", current, from))
+}
+
+// jsonEqual returns true if a & b encode the same JSON value. Numbers are
+// compared by their exact value so 1 and 1.0 are equal.
+func jsonEqual(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	var x, y any
+	if err := decodeJSON(a, &x); err != nil {
+		return bytes.Equal(a, b)
+	} else if err := decodeJSON(b, &y); err != nil {
+		return bytes.Equal(a, b)
+	}
+	return valueEqual(x, y)
+}
+
+func decodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func valueEqual(x, y any) bool {
+	switch x := x.(type) {
+	case json.Number:
+		y, ok := y.(json.Number)
+		if !ok {
+			return false
+		}
+		a, aok := new(big.Rat).SetString(string(x))
+		b, bok := new(big.Rat).SetString(string(y))
+		if !aok || !bok {
+			return x == y
+		}
+		return a.Cmp(b) == 0
+
+	case []any:
+		y, ok := y.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !valueEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+
+	case map[string]any:
+		y, ok := y.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !valueEqual(v, w) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return x == y
+	}
+}
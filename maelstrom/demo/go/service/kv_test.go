@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/service"
+)
+
+func TestLinKV(t *testing.T) {
+	t.Run("ReadKeyDoesNotExist", func(t *testing.T) {
+		s := service.NewLinKV()
+		if got, want := handle(t, s, "c1", `{"type":"read","msg_id":1,"key":"x"}`), `{"type":"error","in_reply_to":1,"code":20,"text":"key does not exist"}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("WriteRead", func(t *testing.T) {
+		s := service.NewLinKV()
+		if got, want := handle(t, s, "c1", `{"type":"write","msg_id":1,"key":"x","value":{"a":[1,2]}}`), `{"type":"write_ok","in_reply_to":1}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"read","msg_id":2,"key":"x"}`), `{"type":"read_ok","in_reply_to":2,"value":{"a":[1,2]}}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("CAS", func(t *testing.T) {
+		s := service.NewLinKV()
+		if got, want := handle(t, s, "c1", `{"type":"cas","msg_id":1,"key":"x","from":1,"to":2}`), `{"type":"error","in_reply_to":1,"code":20,"text":"key does not exist"}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"cas","msg_id":2,"key":"x","from":1,"to":2,"create_if_not_exists":true}`), `{"type":"cas_ok","in_reply_to":2}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"cas","msg_id":3,"key":"x","from":1,"to":3}`), `{"type":"error","in_reply_to":3,"code":22,"text":"current value 2 is not 1"}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+
+		// Numbers are compared by value, not by their encoding.
+		if got, want := handle(t, s, "c1", `{"type":"cas","msg_id":4,"key":"x","from":2.0,"to":3}`), `{"type":"cas_ok","in_reply_to":4}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	// Keys may be any JSON value & are compared by their encoding.
+	t.Run("IntegerKey", func(t *testing.T) {
+		s := service.NewLinKV()
+		if got, want := handle(t, s, "c1", `{"type":"write","msg_id":1,"key":1,"value":"a"}`), `{"type":"write_ok","in_reply_to":1}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"write","msg_id":2,"key":"1","value":"b"}`), `{"type":"write_ok","in_reply_to":2}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"read","msg_id":3,"key":1}`), `{"type":"read_ok","in_reply_to":3,"value":"a"}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"cas","msg_id":4,"key": 1,"from":"a","to":"c"}`), `{"type":"cas_ok","in_reply_to":4}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+		if got, want := handle(t, s, "c1", `{"type":"read","msg_id":5,"key":"1"}`), `{"type":"read_ok","in_reply_to":5,"value":"b"}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("ErrNotSupported", func(t *testing.T) {
+		s := service.NewLinKV()
+		if got, want := handle(t, s, "c1", `{"type":"delete","msg_id":1,"key":"x"}`), `{"type":"error","in_reply_to":1,"code":10,"text":"unsupported request type \"delete\""}`; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+}
+
+// Ensure seq-kv serves stale reads while keeping each client monotonic.
+func TestSeqKV(t *testing.T) {
+	s := service.NewSeqKV(0)
+	for i := 1; i <= 10; i++ {
+		handle(t, s, "c1", fmt.Sprintf(`{"type":"write","msg_id":%d,"key":"x","value":%d}`, i, i))
+	}
+
+	// The writer always observes its own writes.
+	if got, want := handle(t, s, "c1", `{"type":"read","msg_id":11,"key":"x"}`), `{"type":"read_ok","in_reply_to":11,"value":10}`; got != want {
+		t.Fatalf("response=%s, want %s", got, want)
+	}
+
+	// Other clients may observe past states but never go backwards.
+	var stale bool
+	for i := 0; i < 20; i++ {
+		client := fmt.Sprintf("c%d", i+2)
+		prev := -1
+		for j := 0; j < 10; j++ {
+			var body struct {
+				Value int `json:"value"`
+			}
+			resp := handle(t, s, client, `{"type":"read","msg_id":1,"key":"x"}`)
+			if err := json.Unmarshal([]byte(resp), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Value < prev {
+				t.Fatalf("client %s read %d after %d", client, body.Value, prev)
+			} else if body.Value < 10 {
+				stale = true
+			}
+			prev = body.Value
+		}
+	}
+	if !stale {
+		t.Fatal("expected at least one stale read")
+	}
+}
+
+// Ensure lww-kv may lose updates applied to different replicas.
+func TestLWWKV(t *testing.T) {
+	s := service.NewLWWKV(0)
+
+	var missing, found int
+	for i := 0; i < 100; i++ {
+		handle(t, s, "c1", fmt.Sprintf(`{"type":"write","msg_id":%d,"key":"k%d","value":%d}`, i, i, i))
+		var body maelstrom.MessageBody
+		if err := json.Unmarshal([]byte(handle(t, s, "c1", fmt.Sprintf(`{"type":"read","msg_id":%d,"key":"k%d"}`, i, i))), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Code == maelstrom.KeyDoesNotExist {
+			missing++
+		} else {
+			found++
+		}
+	}
+	if missing == 0 || found == 0 {
+		t.Fatalf("expected a mix of missing & found reads, got missing=%d found=%d", missing, found)
+	}
+}
+
+// handle sends a request to svc and returns the JSON-encoded response.
+func handle(tb testing.TB, svc service.Service, src, body string) string {
+	tb.Helper()
+
+	buf, err := json.Marshal(svc.Handle(maelstrom.Message{Src: src, Dest: "svc", Body: json.RawMessage(body)}))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return string(buf)
+}
@@ -0,0 +1,67 @@
+// Package service implements in-process versions of the services provided
+// by the Maelstrom runtime: lin-kv, seq-kv, lww-kv & lin-tso. Each service
+// speaks the same JSON protocol as its Maelstrom counterpart so it can be
+// used by the maelstrom.KV client without running the Maelstrom binary.
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Service represents a Maelstrom-provided service.
+type Service interface {
+	// Handle processes a request message and returns the response body to
+	// send back to msg.Src. The response includes the "in_reply_to" field.
+	Handle(msg maelstrom.Message) any
+}
+
+// Defaults returns the default set of services provided by Maelstrom, keyed
+// by their node ID. The seed is used for services with random behavior.
+func Defaults(seed int64) map[string]Service {
+	return map[string]Service{
+		maelstrom.LinKV: NewLinKV(),
+		maelstrom.SeqKV: NewSeqKV(seed),
+		maelstrom.LWWKV: NewLWWKV(seed),
+		LinTSO:          NewLinTSO(),
+	}
+}
+
+// newRand returns a new random source for seed.
+func newRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// parseRequest returns the reserved fields of a request body.
+func parseRequest(msg maelstrom.Message) (maelstrom.MessageBody, error) {
+	var body maelstrom.MessageBody
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return body, maelstrom.NewRPCError(maelstrom.MalformedRequest, err.Error())
+	}
+	return body, nil
+}
+
+// errorResponse converts err into an error response body for a request.
+func errorResponse(req maelstrom.MessageBody, err error) any {
+	code, text := maelstrom.ErrorCode(err), err.Error()
+	if rpcErr, ok := err.(*maelstrom.RPCError); ok {
+		text = rpcErr.Text
+	} else if code == -1 {
+		code = maelstrom.Crash
+	}
+
+	return maelstrom.MessageBody{
+		Type:      "error",
+		InReplyTo: req.MsgID,
+		Code:      code,
+		Text:      text,
+	}
+}
+
+// notSupported returns the error for an unknown request type.
+func notSupported(typ string) error {
+	return maelstrom.NewRPCError(maelstrom.NotSupported, fmt.Sprintf("unsupported request type %q", typ))
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"sync"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// LinTSO is the node ID of the linearizable timestamp oracle service.
+const LinTSO = "lin-tso"
+
+// TSO is a linearizable timestamp oracle. It responds to "ts" requests with a
+// monotonically increasing sequence of integers, starting at 0.
+type TSO struct {
+	mu sync.Mutex
+	ts int
+}
+
+// NewLinTSO returns a new instance of TSO.
+func NewLinTSO() *TSO {
+	return &TSO{}
+}
+
+// Handle processes a "ts" request.
+func (s *TSO) Handle(msg maelstrom.Message) any {
+	req, err := parseRequest(msg)
+	if err != nil {
+		return errorResponse(req, err)
+	} else if req.Type != "ts" {
+		return errorResponse(req, notSupported(req.Type))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := s.ts
+	s.ts++
+	return tsOKMessageBody{
+		MessageBody: maelstrom.MessageBody{Type: "ts_ok", InReplyTo: req.MsgID},
+		TS:          ts,
+	}
+}
+
+// tsOKMessageBody represents the response body for a "ts" request.
+type tsOKMessageBody struct {
+	maelstrom.MessageBody
+	TS int `json:"ts"`
+}
@@ -0,0 +1,17 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jepsen-io/maelstrom/demo/go/service"
+)
+
+func TestTSO(t *testing.T) {
+	s := service.NewLinTSO()
+	for i := 0; i < 3; i++ {
+		if got, want := handle(t, s, "c1", fmt.Sprintf(`{"type":"ts","msg_id":%d}`, i+1)), fmt.Sprintf(`{"type":"ts_ok","in_reply_to":%d,"ts":%d}`, i+1, i); got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	}
+}
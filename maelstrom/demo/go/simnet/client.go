@@ -0,0 +1,101 @@
+package simnet
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Client represents a Maelstrom client attached to a simulated network.
+// Clients send requests to nodes and wait for their responses.
+type Client struct {
+	mu        sync.Mutex
+	id        string
+	net       *Network
+	nextMsgID int
+	callbacks map[int]chan maelstrom.Message
+}
+
+// ID returns the client's identifier on the network.
+func (c *Client) ID() string {
+	return c.id
+}
+
+// Send sends a message body to a given destination without expecting a reply.
+func (c *Client) Send(dest string, body any) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.net.Route(maelstrom.Message{Src: c.id, Dest: dest, Body: buf})
+	return nil
+}
+
+// SyncRPC sends a request to dest and waits for the response. RPC errors in
+// the response body are converted to *RPCError and are returned.
+func (c *Client) SyncRPC(ctx context.Context, dest string, body any) (maelstrom.Message, error) {
+	// Decode the top-level fields as raw JSON to inject our message ID so
+	// that values such as large integers are passed through unchanged.
+	b := make(map[string]json.RawMessage)
+	if buf, err := json.Marshal(body); err != nil {
+		return maelstrom.Message{}, err
+	} else if err := json.Unmarshal(buf, &b); err != nil {
+		return maelstrom.Message{}, err
+	}
+
+	c.mu.Lock()
+	c.nextMsgID++
+	msgID := c.nextMsgID
+	respCh := make(chan maelstrom.Message, 1)
+	c.callbacks[msgID] = respCh
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.callbacks, msgID)
+		c.mu.Unlock()
+	}()
+
+	b["msg_id"] = json.RawMessage(strconv.Itoa(msgID))
+	if err := c.Send(dest, b); err != nil {
+		return maelstrom.Message{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return maelstrom.Message{}, ctx.Err()
+	case m := <-respCh:
+		if err := m.RPCError(); err != nil {
+			return m, err
+		}
+		return m, nil
+	}
+}
+
+// receive handles a message delivered to the client by the network.
+func (c *Client) receive(msg maelstrom.Message) error {
+	var body maelstrom.MessageBody
+	if err := json.Unmarshal(msg.Body, &body); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	ch := c.callbacks[body.InReplyTo]
+	c.mu.Unlock()
+
+	if ch == nil {
+		log.Printf("simnet: client %s ignoring message with no callback: %s", c.id, msg.Body)
+		return nil
+	}
+
+	// Drop duplicate replies if the first one has not been consumed yet.
+	select {
+	case ch <- msg:
+	default:
+	}
+	return nil
+}
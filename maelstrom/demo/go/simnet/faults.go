@@ -0,0 +1,204 @@
+package simnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// Faults configures the faults injected into messages sent between nodes.
+// Messages to & from clients are never affected.
+type Faults struct {
+	// Fraction of messages, from 0 to 1, which are silently dropped.
+	DropRate float64
+
+	// Fraction of messages, from 0 to 1, which are inserted at a random
+	// position in the destination's delivery queue instead of at the end.
+	ReorderRate float64
+
+	// Optional. Returns the delay before a message is delivered.
+	Latency LatencyFunc
+}
+
+// LatencyFunc returns a delivery delay drawn from the network's random source.
+type LatencyFunc func(rand *rand.Rand) time.Duration
+
+// FixedLatency returns a latency function which always delays by d.
+func FixedLatency(d time.Duration) LatencyFunc {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// UniformLatency returns a latency function with delays evenly distributed
+// between min & max.
+func UniformLatency(min, max time.Duration) LatencyFunc {
+	return func(rand *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(rand.Int63n(int64(max-min)))
+	}
+}
+
+// ExponentialLatency returns a latency function with exponentially
+// distributed delays with the given mean, similar to Maelstrom's
+// "exponential" latency distribution.
+func ExponentialLatency(mean time.Duration) LatencyFunc {
+	return func(rand *rand.Rand) time.Duration {
+		return time.Duration(rand.ExpFloat64() * float64(mean))
+	}
+}
+
+// Seed resets the network's random source. Drops, delays & reorderings are
+// derived from this source so a seed reproduces the same fault decisions.
+func (net *Network) Seed(seed int64) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.rand = rand.New(rand.NewSource(seed))
+}
+
+// SetFaults replaces the fault configuration for inter-node messages.
+func (net *Network) SetFaults(f Faults) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.faults = f
+}
+
+// Partition splits the nodes into the given groups. Messages are only
+// delivered between nodes in the same group; nodes that are not part of
+// any group are isolated from all other nodes.
+func (net *Network) Partition(groups ...[]string) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	net.partition = make(map[string]int)
+	for i, group := range groups {
+		for _, id := range group {
+			net.partition[id] = i
+		}
+	}
+}
+
+// Heal removes any network partition.
+func (net *Network) Heal() {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	net.partition = nil
+}
+
+// Dropped returns the number of messages dropped by faults or partitions.
+func (net *Network) Dropped() int {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	return net.dropped
+}
+
+// connected returns true if a partition allows src to reach dest.
+// Lock must be held.
+func (net *Network) connected(src, dest string) bool {
+	if net.partition == nil {
+		return true
+	}
+	i, ok := net.partition[src]
+	if !ok {
+		return false
+	}
+	j, ok := net.partition[dest]
+	return ok && i == j
+}
+
+// delivery represents the fault decisions made for a single message.
+type delivery struct {
+	drop    bool
+	delay   time.Duration
+	reorder float64 // position in queue, from 0 to 1; negative appends.
+}
+
+// decide determines how a message from src to dest should be delivered.
+// Lock must be held.
+func (net *Network) decide(src, dest string) delivery {
+	d := delivery{reorder: -1}
+	if !net.isNode(src) || !net.isNode(dest) {
+		return d
+	}
+
+	if !net.connected(src, dest) {
+		d.drop = true
+		return d
+	}
+	if net.faults.DropRate > 0 && net.rand.Float64() < net.faults.DropRate {
+		d.drop = true
+		return d
+	}
+	if net.faults.Latency != nil {
+		d.delay = net.faults.Latency(net.rand)
+	}
+	if net.faults.ReorderRate > 0 && net.rand.Float64() < net.faults.ReorderRate {
+		d.reorder = net.rand.Float64()
+	}
+	return d
+}
+
+// Step represents a single action in a fault schedule.
+type Step struct {
+	// Time to wait after the previous step before applying this one.
+	After time.Duration
+
+	// Human-readable description of the step, used for logging.
+	Name string
+
+	// Applies the step to the network.
+	Apply func(net *Network)
+}
+
+// Schedule is a sequence of fault steps applied in order.
+type Schedule []Step
+
+// RunSchedule applies each step of s in order. Returns early with the
+// context's error if ctx is cancelled.
+func (net *Network) RunSchedule(ctx context.Context, s Schedule) error {
+	for _, step := range s {
+		timer := time.NewTimer(step.After)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		log.Printf("simnet: applying %s", step.Name)
+		step.Apply(net)
+	}
+	return nil
+}
+
+// RandomPartitions returns a schedule which alternates between randomly
+// splitting nodeIDs into two groups & healing the network, count times, with
+// interval between each step. The same seed always produces the same
+// schedule.
+func RandomPartitions(seed int64, nodeIDs []string, count int, interval time.Duration) Schedule {
+	rand := rand.New(rand.NewSource(seed))
+
+	var s Schedule
+	for i := 0; i < count; i++ {
+		ids := append([]string(nil), nodeIDs...)
+		rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		var k int
+		if len(ids) > 1 {
+			k = 1 + rand.Intn(len(ids)-1)
+		}
+		a, b := ids[:k], ids[k:]
+
+		s = append(s, Step{
+			After: interval,
+			Name:  fmt.Sprintf("partition %v %v", a, b),
+			Apply: func(net *Network) { net.Partition(a, b) },
+		}, Step{
+			After: interval,
+			Name:  "heal",
+			Apply: func(net *Network) { net.Heal() },
+		})
+	}
+	return s
+}
@@ -0,0 +1,211 @@
+package simnet_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+)
+
+// Ensure partitioned nodes cannot communicate until the network is healed.
+func TestNetwork_Partition(t *testing.T) {
+	net := newRelayNetwork(t)
+	c := net.Client()
+
+	net.Partition([]string{"n1"}, []string{"n2"})
+	if _, err := c.SyncRPC(context.Background(), "n1", map[string]any{"type": "relay"}); maelstrom.ErrorCode(err) != maelstrom.TemporarilyUnavailable {
+		t.Fatalf("unexpected error: %v", err)
+	} else if net.Dropped() == 0 {
+		t.Fatal("expected dropped messages")
+	}
+
+	net.Heal()
+	if _, err := c.SyncRPC(context.Background(), "n1", map[string]any{"type": "relay"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Ensure all inter-node messages are dropped with a drop rate of 1.
+func TestNetwork_DropRate(t *testing.T) {
+	net := newRelayNetwork(t)
+	net.SetFaults(simnet.Faults{DropRate: 1})
+
+	// Client messages are unaffected but the relay to n2 is dropped.
+	if _, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "relay"}); maelstrom.ErrorCode(err) != maelstrom.TemporarilyUnavailable {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure inter-node messages are delayed by the latency function.
+func TestNetwork_Latency(t *testing.T) {
+	net := newRelayNetwork(t)
+	net.SetFaults(simnet.Faults{Latency: simnet.FixedLatency(20 * time.Millisecond)})
+
+	start := time.Now()
+	if _, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "relay"}); err != nil {
+		t.Fatal(err)
+	} else if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("elapsed=%s, expected at least 40ms", elapsed)
+	}
+}
+
+// Ensure a burst of messages between nodes is delivered out of order.
+func TestNetwork_Reorder(t *testing.T) {
+	const count = 20
+
+	net := simnet.NewNetwork()
+	net.Seed(1)
+	net.SetFaults(simnet.Faults{ReorderRate: 1})
+
+	n1 := maelstrom.NewNode()
+	n1.Handle("burst", func(msg maelstrom.Message) error {
+		for i := 0; i < count; i++ {
+			if err := n1.Send("n2", map[string]any{"type": "seq", "seq": i}); err != nil {
+				return err
+			}
+		}
+		return n1.Reply(msg, map[string]any{"type": "burst_ok"})
+	})
+	net.AddNode("n1", n1)
+
+	// Attach n2 as a raw stream which stops reading its input after init so
+	// that the burst queues up in the network.
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	net.AddStream("n2", inw, outr, nil)
+	in := json.NewDecoder(inr)
+	go func() {
+		var msg maelstrom.Message
+		if err := in.Decode(&msg); err != nil {
+			return
+		}
+		var body maelstrom.MessageBody
+		_ = json.Unmarshal(msg.Body, &body)
+		_ = json.NewEncoder(outw).Encode(map[string]any{
+			"src":  "n2",
+			"dest": msg.Src,
+			"body": map[string]any{"type": "init_ok", "in_reply_to": body.MsgID},
+		})
+	}()
+	startNetwork(t, net)
+	t.Cleanup(func() { outw.Close() })
+
+	// Replies are routed after the burst, so every message has been queued.
+	if _, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "burst"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want []int
+	for i := 0; i < count; i++ {
+		var msg struct {
+			Body struct {
+				Seq int `json:"seq"`
+			} `json:"body"`
+		}
+		if err := in.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		got, want = append(got, msg.Body.Seq), append(want, i)
+	}
+
+	if reflect.DeepEqual(got, want) {
+		t.Fatalf("expected reordered delivery, got %v", got)
+	}
+	sort.Ints(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("delivered=%v, want %v", got, want)
+	}
+}
+
+// Ensure the same seed produces the same partition schedule.
+func TestRandomPartitions(t *testing.T) {
+	nodeIDs := []string{"n1", "n2", "n3", "n4", "n5"}
+	names := func(s simnet.Schedule) (a []string) {
+		for _, step := range s {
+			a = append(a, step.Name)
+		}
+		return a
+	}
+
+	a := simnet.RandomPartitions(7, nodeIDs, 5, time.Second)
+	b := simnet.RandomPartitions(7, nodeIDs, 5, time.Second)
+	if got, want := len(a), 10; got != want {
+		t.Fatalf("len=%d, want %d", got, want)
+	} else if !reflect.DeepEqual(names(a), names(b)) {
+		t.Fatalf("schedules differ:\n%q\n%q", names(a), names(b))
+	}
+}
+
+// Ensure a schedule is applied to the network in order.
+func TestNetwork_RunSchedule(t *testing.T) {
+	net := newRelayNetwork(t)
+
+	if err := net.RunSchedule(context.Background(), simnet.Schedule{
+		{Name: "isolate", Apply: func(net *simnet.Network) { net.Partition() }},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "relay"}); maelstrom.ErrorCode(err) != maelstrom.TemporarilyUnavailable {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a node binary can be attached to the network.
+func TestNetwork_AddCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping binary build in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	bin := filepath.Join(t.TempDir(), "maelstrom-echo")
+	if out, err := exec.Command(goBin, "build", "-o", bin, "../cmd/maelstrom-echo").CombinedOutput(); err != nil {
+		t.Fatalf("build: %s\n%s", err, out)
+	}
+
+	net := simnet.NewNetwork()
+	if err := net.AddCommand("n1", exec.Command(bin)); err != nil {
+		t.Fatal(err)
+	}
+	startNetwork(t, net)
+
+	if _, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "echo", "echo": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRelayNetwork returns a started two-node network. A "relay" request to
+// n1 is forwarded to n2 and fails with TemporarilyUnavailable if n2 does not
+// respond quickly.
+func newRelayNetwork(tb testing.TB) *simnet.Network {
+	net := simnet.NewNetwork()
+
+	n1, n2 := maelstrom.NewNode(), maelstrom.NewNode()
+	n1.Handle("relay", func(msg maelstrom.Message) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		if _, err := n1.SyncRPC(ctx, "n2", map[string]any{"type": "ping"}); err != nil {
+			return maelstrom.NewRPCError(maelstrom.TemporarilyUnavailable, err.Error())
+		}
+		return n1.Reply(msg, map[string]any{"type": "relay_ok"})
+	})
+	n2.Handle("ping", func(msg maelstrom.Message) error {
+		return n2.Reply(msg, map[string]any{"type": "pong"})
+	})
+
+	net.AddNode("n1", n1)
+	net.AddNode("n2", n2)
+	startNetwork(tb, net)
+	return net
+}
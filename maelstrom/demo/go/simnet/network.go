@@ -0,0 +1,446 @@
+// Package simnet implements an in-process network for running clusters of
+// maelstrom.Node instances without the Maelstrom binary. Nodes are wired
+// together through their Stdin/Stdout streams and messages are routed by
+// their "dest" field, just like the Maelstrom runtime does.
+package simnet
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/service"
+)
+
+// Network represents a simulated network connecting nodes & clients.
+type Network struct {
+	mu       sync.Mutex
+	readers  sync.WaitGroup
+	delivery sync.WaitGroup
+
+	endpoints map[string]*endpoint
+	nodeIDs   []string
+	clientID  int
+	started   bool
+	closed    bool
+
+	runErrs chan error
+
+	// Fault injection state.
+	rand      *rand.Rand
+	faults    Faults
+	partition map[string]int
+	dropped   int
+}
+
+// NewNetwork returns a new, empty instance of Network.
+func NewNetwork() *Network {
+	return &Network{
+		endpoints: make(map[string]*endpoint),
+		rand:      rand.New(rand.NewSource(0)),
+	}
+}
+
+// NodeIDs returns the IDs of all nodes added to the network, in the order
+// they were added. This is the list sent to nodes in their "init" message.
+func (net *Network) NodeIDs() []string {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	return append([]string(nil), net.nodeIDs...)
+}
+
+// AddNode attaches n to the network under the given ID. The node's Stdin and
+// Stdout are replaced with pipes connected to the network. Must be called
+// before Start.
+func (net *Network) AddNode(id string, n *maelstrom.Node) {
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	n.Stdin, n.Stdout = inr, outw
+
+	net.addStream(id, inw, outr, func() error {
+		defer outw.Close()
+		return n.Run()
+	})
+}
+
+// AddStream attaches an arbitrary message stream to the network under the
+// given ID. Messages routed to id are written as JSON lines to w and lines
+// read from r are routed to their destination. The run function, if not nil,
+// is executed by Start and should block until the stream is finished; w is
+// closed by Close to signal that the stream should stop.
+//
+// This can be used to connect nodes running in external processes.
+func (net *Network) AddStream(id string, w io.WriteCloser, r io.Reader, run func() error) {
+	net.addStream(id, w, r, run)
+}
+
+// AddCommand attaches a node binary to the network under the given ID. The
+// command is started by Start and its STDIN/STDOUT are connected to the
+// network. STDERR defaults to the test process' STDERR if not set.
+func (net *Network) AddCommand(id string, cmd *exec.Cmd) error {
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	// Use our own pipe for STDOUT as exec closes its pipes when the process
+	// exits, which could race with reading the remaining output.
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = pw
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	net.addStream(id, w, r, func() error {
+		err := cmd.Start()
+		pw.Close()
+		if err != nil {
+			r.Close()
+			return err
+		}
+		return cmd.Wait()
+	})
+	return nil
+}
+
+func (net *Network) addStream(id string, w io.WriteCloser, r io.Reader, run func() error) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	if net.started {
+		panic("simnet: cannot add node after network has started")
+	} else if _, ok := net.endpoints[id]; ok {
+		panic(fmt.Sprintf("simnet: duplicate endpoint %q", id))
+	}
+
+	ep := newEndpoint(id, func(msg maelstrom.Message) error {
+		buf, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(buf, '\n'))
+		return err
+	})
+	ep.closer = w
+	ep.reader = r
+	ep.run = run
+	ep.node = true
+
+	net.endpoints[id] = ep
+	net.nodeIDs = append(net.nodeIDs, id)
+}
+
+// AddService attaches a service to the network under the given ID, such as
+// a service.LinKV under "lin-kv". Requests are handled one at a time in the
+// order they are delivered.
+func (net *Network) AddService(id string, svc service.Service) {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	if _, ok := net.endpoints[id]; ok {
+		panic(fmt.Sprintf("simnet: duplicate endpoint %q", id))
+	}
+
+	ep := newEndpoint(id, func(msg maelstrom.Message) error {
+		buf, err := json.Marshal(svc.Handle(msg))
+		if err != nil {
+			return err
+		}
+		net.Route(maelstrom.Message{Src: id, Dest: msg.Src, Body: buf})
+		return nil
+	})
+	net.endpoints[id] = ep
+
+	if net.started {
+		net.startDelivery(ep)
+	}
+}
+
+// Client returns a new client attached to the network. Client IDs are
+// assigned sequentially as "c1", "c2", etc.
+func (net *Network) Client() *Client {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	net.clientID++
+	return net.newClient(fmt.Sprintf("c%d", net.clientID))
+}
+
+// newClient returns a new client registered with id. Lock must be held.
+func (net *Network) newClient(id string) *Client {
+	c := &Client{
+		id:        id,
+		net:       net,
+		callbacks: make(map[int]chan maelstrom.Message),
+	}
+	ep := newEndpoint(id, c.receive)
+	net.endpoints[id] = ep
+
+	// Clients may be created after the network starts so begin delivering now.
+	if net.started {
+		net.startDelivery(ep)
+	}
+	return c
+}
+
+// Start runs every node and sends each one an "init" message. Returns once
+// all nodes have acknowledged their initialization.
+func (net *Network) Start(ctx context.Context) error {
+	net.mu.Lock()
+	if net.started {
+		net.mu.Unlock()
+		return errors.New("simnet: network already started")
+	}
+	net.started = true
+
+	var streams []*endpoint
+	for _, id := range net.nodeIDs {
+		streams = append(streams, net.endpoints[id])
+	}
+	for _, ep := range net.endpoints {
+		net.startDelivery(ep)
+	}
+	net.runErrs = make(chan error, len(streams))
+	init := net.newClient("c0")
+	nodeIDs := append([]string(nil), net.nodeIDs...)
+	net.mu.Unlock()
+
+	for _, ep := range streams {
+		ep := ep
+
+		// Read outbound messages from the node & route them.
+		net.readers.Add(1)
+		go func() {
+			defer net.readers.Done()
+			net.readStream(ep)
+		}()
+
+		if ep.run != nil {
+			go func() { net.runErrs <- ep.run() }()
+		} else {
+			net.runErrs <- nil
+		}
+	}
+
+	// Initialize all nodes.
+	for _, id := range nodeIDs {
+		if _, err := init.SyncRPC(ctx, id, maelstrom.InitMessageBody{
+			MessageBody: maelstrom.MessageBody{Type: "init"},
+			NodeID:      id,
+			NodeIDs:     nodeIDs,
+		}); err != nil {
+			return fmt.Errorf("init %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Close shuts down the network by closing the input of every node and
+// waiting for the nodes to finish. Returns the first error returned by a node.
+func (net *Network) Close() error {
+	net.mu.Lock()
+	if net.closed {
+		net.mu.Unlock()
+		return nil
+	}
+	net.closed = true
+	started := net.started
+
+	var streams []*endpoint
+	for _, id := range net.nodeIDs {
+		streams = append(streams, net.endpoints[id])
+	}
+	net.mu.Unlock()
+
+	// Stop delivering to nodes and signal them to stop reading input.
+	for _, ep := range streams {
+		ep.close()
+		if err := ep.closer.Close(); err != nil {
+			log.Printf("simnet: close %s: %s", ep.id, err)
+		}
+	}
+	if !started {
+		return nil
+	}
+
+	// Wait for all nodes to finish running.
+	var firstErr error
+	for range streams {
+		if err := <-net.runErrs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	net.readers.Wait()
+
+	// Stop client delivery after all node output has been routed.
+	net.mu.Lock()
+	for _, ep := range net.endpoints {
+		ep.close()
+	}
+	net.mu.Unlock()
+	net.delivery.Wait()
+
+	return firstErr
+}
+
+// readStream reads JSON lines from an endpoint's output and routes them.
+func (net *Network) readStream(ep *endpoint) {
+	rd := bufio.NewReader(ep.reader)
+	for {
+		line, err := rd.ReadBytes('\n')
+		if len(line) > 0 {
+			var msg maelstrom.Message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				log.Printf("simnet: malformed message from %s: %s", ep.id, err)
+			} else {
+				net.Route(msg)
+			}
+		}
+		if err != nil {
+			if err != io.EOF && !errors.Is(err, io.ErrClosedPipe) {
+				log.Printf("simnet: read %s: %s", ep.id, err)
+			}
+			return
+		}
+	}
+}
+
+// Route delivers msg to the endpoint identified by msg.Dest. Messages to
+// unknown destinations are logged & dropped. Messages between nodes are
+// subject to the network's faults & partitions.
+func (net *Network) Route(msg maelstrom.Message) {
+	net.mu.Lock()
+	ep := net.endpoints[msg.Dest]
+	d := net.decide(msg.Src, msg.Dest)
+	if d.drop {
+		net.dropped++
+	}
+	net.mu.Unlock()
+
+	if ep == nil {
+		log.Printf("simnet: dropping message to unknown destination %q: %s", msg.Dest, msg.Body)
+		return
+	} else if d.drop {
+		log.Printf("simnet: dropping message from %s to %s: %s", msg.Src, msg.Dest, msg.Body)
+		return
+	}
+
+	if d.delay > 0 {
+		time.AfterFunc(d.delay, func() { ep.enqueue(msg, d.reorder) })
+		return
+	}
+	ep.enqueue(msg, d.reorder)
+}
+
+// isNode returns true if id refers to a node, rather than a client.
+// Lock must be held.
+func (net *Network) isNode(id string) bool {
+	ep := net.endpoints[id]
+	return ep != nil && ep.node
+}
+
+// startDelivery starts the goroutine which delivers queued messages to ep.
+func (net *Network) startDelivery(ep *endpoint) {
+	net.delivery.Add(1)
+	go func() {
+		defer net.delivery.Done()
+		ep.deliverLoop()
+	}()
+}
+
+// endpoint represents an addressable participant on the network.
+type endpoint struct {
+	id      string
+	deliver func(maelstrom.Message) error
+
+	// Only set for streams.
+	node   bool
+	closer io.Closer
+	reader io.Reader
+	run    func() error
+
+	mu     sync.Mutex
+	queue  []maelstrom.Message
+	notify chan struct{}
+	closed bool
+}
+
+func newEndpoint(id string, deliver func(maelstrom.Message) error) *endpoint {
+	return &endpoint{
+		id:      id,
+		deliver: deliver,
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// enqueue adds msg to the endpoint's delivery queue. The message is inserted
+// at the relative position pos, from 0 to 1, or appended if pos is negative.
+func (ep *endpoint) enqueue(msg maelstrom.Message, pos float64) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.closed {
+		return
+	}
+
+	if i := int(pos * float64(len(ep.queue))); pos >= 0 && i < len(ep.queue) {
+		ep.queue = append(ep.queue[:i+1], ep.queue[i:]...)
+		ep.queue[i] = msg
+	} else {
+		ep.queue = append(ep.queue, msg)
+	}
+	ep.signal()
+}
+
+// close stops delivery to the endpoint. Queued messages are discarded.
+func (ep *endpoint) close() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.closed = true
+	ep.queue = nil
+	ep.signal()
+}
+
+// signal wakes up the delivery loop. Lock must be held.
+func (ep *endpoint) signal() {
+	select {
+	case ep.notify <- struct{}{}:
+	default:
+	}
+}
+
+// deliverLoop delivers queued messages in order until the endpoint is closed.
+func (ep *endpoint) deliverLoop() {
+	for range ep.notify {
+		for {
+			ep.mu.Lock()
+			if ep.closed {
+				ep.mu.Unlock()
+				return
+			} else if len(ep.queue) == 0 {
+				ep.mu.Unlock()
+				break
+			}
+			msg := ep.queue[0]
+			ep.queue = ep.queue[1:]
+			ep.mu.Unlock()
+
+			if err := ep.deliver(msg); err != nil {
+				log.Printf("simnet: deliver to %s: %s", ep.id, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package simnet_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/service"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+)
+
+// Ensure a single node can act as an echo server on the network.
+func TestNetwork_Echo(t *testing.T) {
+	net := simnet.NewNetwork()
+	n := maelstrom.NewNode()
+	n.Handle("echo", func(msg maelstrom.Message) error {
+		var body map[string]any
+		if err := json.Unmarshal(msg.Body, &body); err != nil {
+			return err
+		}
+		body["type"] = "echo_ok"
+		return n.Reply(msg, body)
+	})
+	net.AddNode("n1", n)
+	startNetwork(t, net)
+
+	if got, want := n.ID(), "n1"; got != want {
+		t.Fatalf("ID=%s, want %s", got, want)
+	}
+
+	c := net.Client()
+	resp, err := c.SyncRPC(context.Background(), "n1", map[string]any{"type": "echo", "echo": "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatal(err)
+	} else if got, want := body["echo"], "hello"; got != want {
+		t.Fatalf("echo=%v, want %v", got, want)
+	} else if got, want := resp.Src, "n1"; got != want {
+		t.Fatalf("Src=%s, want %s", got, want)
+	} else if got, want := resp.Dest, c.ID(); got != want {
+		t.Fatalf("Dest=%s, want %s", got, want)
+	}
+}
+
+// Ensure request bodies are sent without losing numeric precision.
+func TestClient_SyncRPC_LargeInt(t *testing.T) {
+	net := simnet.NewNetwork()
+	n := maelstrom.NewNode()
+	n.Handle("echo", func(msg maelstrom.Message) error {
+		var body struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(msg.Body, &body); err != nil {
+			return err
+		}
+		return n.Reply(msg, map[string]any{"type": "echo_ok", "value": body.Value})
+	})
+	net.AddNode("n1", n)
+	startNetwork(t, net)
+
+	resp, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "echo", "value": uint64(1<<53 + 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatal(err)
+	} else if got, want := string(body.Value), "9007199254740993"; got != want {
+		t.Fatalf("value=%s, want %s", got, want)
+	}
+}
+
+// Ensure RPC errors returned by a node are surfaced to the client.
+func TestNetwork_RPCError(t *testing.T) {
+	net := simnet.NewNetwork()
+	n := maelstrom.NewNode()
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		return maelstrom.NewRPCError(maelstrom.KeyDoesNotExist, "no key")
+	})
+	net.AddNode("n1", n)
+	startNetwork(t, net)
+
+	_, err := net.Client().SyncRPC(context.Background(), "n1", map[string]any{"type": "foo"})
+	if got, want := maelstrom.ErrorCode(err), maelstrom.KeyDoesNotExist; got != want {
+		t.Fatalf("code=%d, want %d", got, want)
+	}
+}
+
+// Ensure a 5-node cluster can gossip broadcast messages to every node.
+func TestNetwork_Broadcast(t *testing.T) {
+	net := simnet.NewNetwork()
+	for i := 1; i <= 5; i++ {
+		net.AddNode(fmt.Sprintf("n%d", i), newBroadcastNode())
+	}
+	startNetwork(t, net)
+
+	// Broadcast a different value to each node.
+	c := net.Client()
+	for i, id := range net.NodeIDs() {
+		if _, err := c.SyncRPC(context.Background(), id, map[string]any{"type": "broadcast", "message": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Ensure every node eventually sees every value.
+	for _, id := range net.NodeIDs() {
+		waitFor(t, func() bool {
+			resp, err := c.SyncRPC(context.Background(), id, map[string]any{"type": "read"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body struct {
+				Messages []int `json:"messages"`
+			}
+			if err := json.Unmarshal(resp.Body, &body); err != nil {
+				t.Fatal(err)
+			}
+			sort.Ints(body.Messages)
+			return reflect.DeepEqual(body.Messages, []int{0, 1, 2, 3, 4})
+		})
+	}
+}
+
+// Ensure nodes can use a KV service attached to the network.
+func TestNetwork_AddService(t *testing.T) {
+	net := simnet.NewNetwork()
+	for id, svc := range service.Defaults(0) {
+		net.AddService(id, svc)
+	}
+	for i := 1; i <= 3; i++ {
+		n := maelstrom.NewNode()
+		kv := maelstrom.NewLinKV(n)
+		n.Handle("add", func(msg maelstrom.Message) error {
+			var body struct {
+				Delta int `json:"delta"`
+			}
+			if err := json.Unmarshal(msg.Body, &body); err != nil {
+				return err
+			}
+			for {
+				v, err := kv.ReadInt(context.Background(), "counter")
+				if err != nil && maelstrom.ErrorCode(err) != maelstrom.KeyDoesNotExist {
+					return err
+				}
+				err = kv.CompareAndSwap(context.Background(), "counter", v, v+body.Delta, true)
+				if maelstrom.ErrorCode(err) == maelstrom.PreconditionFailed {
+					continue
+				} else if err != nil {
+					return err
+				}
+				return n.Reply(msg, map[string]any{"type": "add_ok"})
+			}
+		})
+		net.AddNode(fmt.Sprintf("n%d", i), n)
+	}
+	startNetwork(t, net)
+
+	// Concurrently add to the counter through every node.
+	var wg sync.WaitGroup
+	for _, id := range net.NodeIDs() {
+		for i := 0; i < 10; i++ {
+			id, c := id, net.Client()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := c.SyncRPC(context.Background(), id, map[string]any{"type": "add", "delta": 1}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	resp, err := net.Client().SyncRPC(context.Background(), "lin-kv", map[string]any{"type": "read", "key": "counter"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Value int `json:"value"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatal(err)
+	} else if got, want := body.Value, 30; got != want {
+		t.Fatalf("counter=%d, want %d", got, want)
+	}
+}
+
+// newBroadcastNode returns a node which floods new broadcast values to its peers.
+func newBroadcastNode() *maelstrom.Node {
+	n := maelstrom.NewNode()
+
+	var mu sync.Mutex
+	seen := make(map[int]struct{})
+
+	n.Handle("broadcast", func(msg maelstrom.Message) error {
+		var body struct {
+			Message int `json:"message"`
+		}
+		if err := json.Unmarshal(msg.Body, &body); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		_, ok := seen[body.Message]
+		seen[body.Message] = struct{}{}
+		mu.Unlock()
+
+		if !ok {
+			for _, id := range n.NodeIDs() {
+				if id != n.ID() && id != msg.Src {
+					if err := n.Send(id, map[string]any{"type": "broadcast", "message": body.Message}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return n.Reply(msg, map[string]any{"type": "broadcast_ok"})
+	})
+
+	n.Handle("broadcast_ok", func(msg maelstrom.Message) error { return nil })
+
+	n.Handle("read", func(msg maelstrom.Message) error {
+		mu.Lock()
+		messages := make([]int, 0, len(seen))
+		for v := range seen {
+			messages = append(messages, v)
+		}
+		mu.Unlock()
+		return n.Reply(msg, map[string]any{"type": "read_ok", "messages": messages})
+	})
+
+	return n
+}
+
+// startNetwork starts net and ensures that it is closed by the end of the test.
+func startNetwork(tb testing.TB, net *simnet.Network) {
+	tb.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() {
+		if err := net.Close(); err != nil {
+			tb.Fatalf("close network: %s", err)
+		}
+	})
+}
+
+// waitFor polls fn until it returns true or fails the test after a timeout.
+func waitFor(tb testing.TB, fn func() bool) {
+	tb.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for !fn() {
+		select {
+		case <-timeout:
+			tb.Fatal("timeout waiting for condition")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package maelstrom
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the RPC latency histogram buckets.
+// Latencies above the last bound are counted in a final, unbounded bucket.
+var latencyBounds = []time.Duration{
+	1 * time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+// Stats represents a snapshot of the message statistics of a node.
+type Stats struct {
+	NodeID string    `json:"node_id"`
+	Time   time.Time `json:"time"`
+
+	// Messages & bytes sent & received, keyed by message type.
+	SentByType     map[string]MessageCount `json:"sent_by_type"`
+	ReceivedByType map[string]MessageCount `json:"received_by_type"`
+
+	// Messages & bytes sent & received, keyed by peer ID.
+	SentByPeer     map[string]MessageCount `json:"sent_by_peer"`
+	ReceivedByPeer map[string]MessageCount `json:"received_by_peer"`
+
+	// Latency of completed RPCs, keyed by destination.
+	RPCLatency map[string]LatencyHistogram `json:"rpc_latency"`
+
+	// Number of RPCs awaiting a response.
+	InFlightCallbacks int `json:"in_flight_callbacks"`
+
+	// Number of handler & callback errors, keyed by message type.
+	HandlerErrors map[string]int `json:"handler_errors"`
+
+	// Number of recovered handler & callback panics.
+	Panics int `json:"panics"`
+}
+
+// MessageCount represents a count of messages and their total JSON-encoded
+// size in bytes, excluding newlines.
+type MessageCount struct {
+	Messages int `json:"messages"`
+	Bytes    int `json:"bytes"`
+}
+
+// LatencyHistogram represents the distribution of RPC latencies. Latencies
+// are reported in milliseconds.
+type LatencyHistogram struct {
+	Count   int             `json:"count"`
+	Mean    float64         `json:"mean_ms"`
+	Min     float64         `json:"min_ms"`
+	Max     float64         `json:"max_ms"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket represents the number of latencies which fell into a bucket.
+// LE is the inclusive upper bound of the bucket, such as "10ms", or "+Inf".
+type LatencyBucket struct {
+	LE    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+// statsRecorder accumulates message statistics for a node.
+type statsRecorder struct {
+	mu             sync.Mutex
+	sentByType     map[string]MessageCount
+	receivedByType map[string]MessageCount
+	sentByPeer     map[string]MessageCount
+	receivedByPeer map[string]MessageCount
+	rpcLatency     map[string]*histogram
+	handlerErrors  map[string]int
+	panics         int
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{
+		sentByType:     make(map[string]MessageCount),
+		receivedByType: make(map[string]MessageCount),
+		sentByPeer:     make(map[string]MessageCount),
+		receivedByPeer: make(map[string]MessageCount),
+		rpcLatency:     make(map[string]*histogram),
+		handlerErrors:  make(map[string]int),
+	}
+}
+
+// sent records a message of typ & size sent to dest.
+func (s *statsRecorder) sent(typ, dest string, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sentByType[typ] = s.sentByType[typ].add(size)
+	s.sentByPeer[dest] = s.sentByPeer[dest].add(size)
+}
+
+// received records a message of typ & size received from src.
+func (s *statsRecorder) received(typ, src string, size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receivedByType[typ] = s.receivedByType[typ].add(size)
+	s.receivedByPeer[src] = s.receivedByPeer[src].add(size)
+}
+
+// rpc records the latency of a completed RPC to dest.
+func (s *statsRecorder) rpc(dest string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.rpcLatency[dest]
+	if h == nil {
+		h = &histogram{counts: make([]int, len(latencyBounds)+1)}
+		s.rpcLatency[dest] = h
+	}
+	h.observe(d)
+}
+
+// handlerError records an error returned by a handler for a message of typ.
+func (s *statsRecorder) handlerError(typ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlerErrors[typ]++
+}
+
+// panic records a recovered panic and returns the total number of panics.
+func (s *statsRecorder) panic() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.panics++
+	return s.panics
+}
+
+// snapshot returns a copy of the current statistics.
+func (s *statsRecorder) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	other := Stats{
+		SentByType:     copyCounts(s.sentByType),
+		ReceivedByType: copyCounts(s.receivedByType),
+		SentByPeer:     copyCounts(s.sentByPeer),
+		ReceivedByPeer: copyCounts(s.receivedByPeer),
+		RPCLatency:     make(map[string]LatencyHistogram, len(s.rpcLatency)),
+		HandlerErrors:  make(map[string]int, len(s.handlerErrors)),
+		Panics:         s.panics,
+	}
+	for dest, h := range s.rpcLatency {
+		other.RPCLatency[dest] = h.snapshot()
+	}
+	for typ, v := range s.handlerErrors {
+		other.HandlerErrors[typ] = v
+	}
+	return other
+}
+
+func (c MessageCount) add(size int) MessageCount {
+	return MessageCount{Messages: c.Messages + 1, Bytes: c.Bytes + size}
+}
+
+func copyCounts(m map[string]MessageCount) map[string]MessageCount {
+	other := make(map[string]MessageCount, len(m))
+	for k, v := range m {
+		other[k] = v
+	}
+	return other
+}
+
+// histogram accumulates latencies into buckets defined by latencyBounds.
+type histogram struct {
+	counts   []int
+	sum      time.Duration
+	min, max time.Duration
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBounds) && d > latencyBounds[i] {
+		i++
+	}
+	h.counts[i]++
+
+	if h.count() == 1 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.sum += d
+}
+
+func (h *histogram) count() (n int) {
+	for _, c := range h.counts {
+		n += c
+	}
+	return n
+}
+
+func (h *histogram) snapshot() LatencyHistogram {
+	other := LatencyHistogram{
+		Count:   h.count(),
+		Min:     milliseconds(h.min),
+		Max:     milliseconds(h.max),
+		Buckets: make([]LatencyBucket, len(h.counts)),
+	}
+	if other.Count > 0 {
+		other.Mean = milliseconds(h.sum) / float64(other.Count)
+	}
+
+	for i, c := range h.counts {
+		other.Buckets[i] = LatencyBucket{LE: "+Inf", Count: c}
+		if i < len(latencyBounds) {
+			other.Buckets[i].LE = latencyBounds[i].String()
+		}
+	}
+	return other
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Stats returns a snapshot of the node's message statistics. Statistics are
+// also available to other nodes & clients through the built-in "stats" RPC,
+// unless a "stats" handler has been registered.
+func (n *Node) Stats() Stats {
+	stats := n.stats.snapshot()
+	stats.NodeID = n.ID()
+	stats.Time = n.Clock.Now()
+
+	n.mu.Lock()
+	stats.InFlightCallbacks = len(n.callbacks)
+	n.mu.Unlock()
+
+	return stats
+}
+
+// DumpStats writes a snapshot of the node's statistics to w as a line of JSON
+// every interval, measured by the node's Clock, such as to os.Stderr or a
+// file. A final snapshot is written when the node shuts down. Should be
+// called before Run().
+func (n *Node) DumpStats(w io.Writer, interval time.Duration) {
+	enc := json.NewEncoder(w)
+	dump := func(context.Context) error {
+		if err := enc.Encode(n.Stats()); err != nil {
+			log.Printf("dump stats error: %s", err)
+		}
+		return nil
+	}
+
+	// The final snapshot is written once the periodic task has stopped so
+	// that writes to w are never concurrent.
+	task := n.Every(interval, 0, dump)
+	n.Go(func(ctx context.Context) error {
+		<-task.Done()
+		return dump(ctx)
+	})
+}
+
+// handleStatsMessage replies to a built-in "stats" request.
+func (n *Node) handleStatsMessage(msg Message) error {
+	stats := n.Stats()
+	return n.Reply(msg, struct {
+		MessageBody
+		Stats
+	}{MessageBody: MessageBody{Type: "stats_ok"}, Stats: stats})
+}
@@ -0,0 +1,137 @@
+package maelstrom_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Ensure a node tracks messages, RPC latencies & handler errors.
+func TestNode_Stats(t *testing.T) {
+	n, stdin, stdout := newNode(t)
+	n.Handle("foo", func(msg maelstrom.Message) error {
+		return n.RPC("n2", map[string]any{"type": "bar"}, func(msg maelstrom.Message) error { return nil })
+	})
+	n.Handle("fail", func(msg maelstrom.Message) error {
+		return maelstrom.NewRPCError(maelstrom.Abort, "marker")
+	})
+	initNode(t, n, "n1", []string{"n1", "n2"}, stdin, stdout)
+
+	// Send an RPC to n2 and leave it in-flight.
+	if _, err := stdin.Write([]byte(`{"src":"c1","dest":"n1","body":{"type":"foo","msg_id":2}}` + "\n")); err != nil {
+		t.Fatal(err)
+	} else if _, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n.Stats().InFlightCallbacks, 1; got != want {
+		t.Fatalf("InFlightCallbacks=%d, want %d", got, want)
+	}
+
+	// Respond to the RPC & trigger a handler error.
+	if _, err := stdin.Write([]byte(`{"src":"n2","dest":"n1","body":{"type":"bar_ok","in_reply_to":1}}` + "\n")); err != nil {
+		t.Fatal(err)
+	} else if _, err := stdin.Write([]byte(`{"src":"c1","dest":"n1","body":{"type":"fail","msg_id":3}}` + "\n")); err != nil {
+		t.Fatal(err)
+	} else if _, err := stdout.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fetch stats through the built-in RPC.
+	if _, err := stdin.Write([]byte(`{"src":"c1","dest":"n1","body":{"type":"stats","msg_id":4}}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := stdout.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg maelstrom.Message
+	var body struct {
+		maelstrom.MessageBody
+		maelstrom.Stats
+	}
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(msg.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := body.Type, "stats_ok"; got != want {
+		t.Fatalf("type=%s, want %s", got, want)
+	} else if got, want := body.NodeID, "n1"; got != want {
+		t.Fatalf("NodeID=%s, want %s", got, want)
+	} else if got, want := body.ReceivedByType["foo"].Messages, 1; got != want {
+		t.Fatalf("ReceivedByType[foo]=%d, want %d", got, want)
+	} else if got, want := body.ReceivedByPeer["c1"].Messages, 3; got != want {
+		t.Fatalf("ReceivedByPeer[c1]=%d, want %d", got, want)
+	} else if got, want := body.SentByType["bar"].Messages, 1; got != want {
+		t.Fatalf("SentByType[bar]=%d, want %d", got, want)
+	} else if got, want := body.SentByType["bar"].Bytes, len(`{"src":"n1","dest":"n2","body":{"msg_id":1,"type":"bar"}}`); got != want {
+		t.Fatalf("SentByType[bar].Bytes=%d, want %d", got, want)
+	} else if got, want := body.SentByPeer["c1"].Messages, 1; got != want {
+		t.Fatalf("SentByPeer[c1]=%d, want %d", got, want)
+	} else if got, want := body.RPCLatency["n2"].Count, 1; got != want {
+		t.Fatalf("RPCLatency[n2].Count=%d, want %d", got, want)
+	} else if got, want := len(body.RPCLatency["n2"].Buckets), 13; got != want {
+		t.Fatalf("len(Buckets)=%d, want %d", got, want)
+	} else if got, want := body.InFlightCallbacks, 0; got != want {
+		t.Fatalf("InFlightCallbacks=%d, want %d", got, want)
+	} else if got, want := body.HandlerErrors["fail"], 1; got != want {
+		t.Fatalf("HandlerErrors[fail]=%d, want %d", got, want)
+	}
+}
+
+// Ensure stats are written as JSON lines every interval & on shutdown.
+func TestNode_DumpStats(t *testing.T) {
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	clock := maelstrom.NewManualClock(time.Unix(0, 0))
+	n := maelstrom.NewNode()
+	n.Stdin = inr
+	n.Stdout = outw
+	n.Clock = clock
+
+	statsr, statsw := io.Pipe()
+	dec := json.NewDecoder(statsr)
+	n.DumpStats(statsw, time.Hour)
+
+	done := make(chan error)
+	go func() { done <- n.Run() }()
+
+	// Wait for initialization.
+	if _, err := inw.Write([]byte(`{"body":{"type":"init","msg_id":1,"node_id":"n1","node_ids":["n1"]}}` + "\n")); err != nil {
+		t.Fatal(err)
+	} else if _, err := bufio.NewReader(outr).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure a snapshot is written once the interval elapses.
+	waitUntil(t, func() bool { return clock.Timers() == 1 })
+	clock.Advance(time.Hour)
+
+	var stats maelstrom.Stats
+	if err := dec.Decode(&stats); err != nil {
+		t.Fatal(err)
+	} else if got, want := stats.NodeID, "n1"; got != want {
+		t.Fatalf("NodeID=%s, want %s", got, want)
+	} else if got, want := stats.Time, time.Unix(0, 0).Add(time.Hour); !got.Equal(want) {
+		t.Fatalf("Time=%s, want %s", got, want)
+	} else if got, want := stats.SentByType["init_ok"].Messages, 1; got != want {
+		t.Fatalf("SentByType[init_ok]=%d, want %d", got, want)
+	}
+
+	// Ensure a final snapshot is written on shutdown.
+	if err := inw.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := dec.Decode(&stats); err != nil {
+		t.Fatal(err)
+	} else if got, want := stats.NodeID, "n1"; got != want {
+		t.Fatalf("NodeID=%s, want %s", got, want)
+	} else if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
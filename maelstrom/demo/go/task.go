@@ -0,0 +1,131 @@
+package maelstrom
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Task represents a function scheduled with Node.After or Node.Every. Tasks
+// execute as background workers of the node and stop when it shuts down.
+type Task struct {
+	mu     sync.Mutex
+	paused bool
+	wake   chan struct{} // signalled when paused or resumed
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Pause prevents the task from executing until it is resumed. A run which
+// is already executing is not interrupted.
+func (t *Task) Pause() {
+	t.setPaused(true)
+}
+
+// Resume resumes a paused task. The delay before the next run restarts from
+// the time it is resumed.
+func (t *Task) Resume() {
+	t.setPaused(false)
+}
+
+func (t *Task) setPaused(v bool) {
+	t.mu.Lock()
+	t.paused = v
+	t.mu.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *Task) isPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.paused
+}
+
+// Stop permanently stops the task. The context passed to a running execution
+// is cancelled. Use Done() to wait for it to return.
+func (t *Task) Stop() {
+	t.cancel()
+}
+
+// Done returns a channel which is closed once the task has stopped. This
+// occurs after Stop(), after the single run of an After() task or when the
+// node shuts down.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// After executes fn once after delay d, measured by the node's Clock.
+//
+// Like Go(), an error other than a context cancellation returned by fn shuts
+// down the node. Must be called before Run() returns.
+func (n *Node) After(d time.Duration, fn func(ctx context.Context) error) *Task {
+	return n.schedule(func() time.Duration { return d }, false, fn)
+}
+
+// Every executes fn repeatedly, waiting interval between the end of one run
+// and the start of the next. Jitter is the fraction of each interval, from 0
+// to 1, which is randomized so that nodes do not act in lockstep. A jitter of
+// 0.2 produces intervals between 80% and 120% of interval.
+//
+// Like Go(), an error other than a context cancellation returned by fn shuts
+// down the node. Must be called before Run() returns.
+func (n *Node) Every(interval time.Duration, jitter float64, fn func(ctx context.Context) error) *Task {
+	return n.schedule(func() time.Duration { return jittered(interval, jitter) }, true, fn)
+}
+
+// schedule starts a background worker which executes fn after each delay.
+func (n *Node) schedule(delay func() time.Duration, repeat bool, fn func(ctx context.Context) error) *Task {
+	ctx, cancel := context.WithCancel(n.ctx)
+	t := &Task{
+		wake:   make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	n.Go(func(context.Context) error {
+		defer close(t.done)
+		defer cancel()
+
+		for {
+			// Wait while paused.
+			for t.isPaused() {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-t.wake:
+				}
+			}
+
+			timer := n.Clock.NewTimer(delay())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-t.wake:
+				timer.Stop()
+				continue // restart delay after pause or resume
+			case <-timer.C():
+			}
+
+			if err := fn(ctx); err != nil {
+				return err
+			} else if !repeat {
+				return nil
+			}
+		}
+	})
+	return t
+}
+
+// jittered randomizes the given fraction of d.
+func jittered(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + jitter*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,156 @@
+package maelstrom_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+func TestNode_Every(t *testing.T) {
+	clock := maelstrom.NewManualClock(time.Unix(0, 0))
+	n := maelstrom.NewNode()
+	n.Clock = clock
+	runNode(t, n)
+
+	runs := make(chan time.Time, 10)
+	task := n.Every(time.Second, 0, func(ctx context.Context) error {
+		runs <- clock.Now()
+		return nil
+	})
+
+	// advance moves the clock forward once the task is waiting.
+	advance := func(d time.Duration) {
+		t.Helper()
+		waitUntil(t, func() bool { return clock.Timers() == 1 })
+		clock.Advance(d)
+	}
+	expectRun := func(want time.Time) {
+		t.Helper()
+		select {
+		case got := <-runs:
+			if !got.Equal(want) {
+				t.Fatalf("run at %s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for run")
+		}
+	}
+
+	advance(500 * time.Millisecond)
+	advance(500 * time.Millisecond)
+	expectRun(time.Unix(1, 0))
+	advance(time.Second)
+	expectRun(time.Unix(2, 0))
+
+	// Ensure a paused task does not run.
+	task.Pause()
+	waitUntil(t, func() bool { return clock.Timers() == 0 })
+	clock.Advance(5 * time.Second)
+	select {
+	case <-runs:
+		t.Fatal("unexpected run while paused")
+	default:
+	}
+
+	// Ensure the interval restarts once resumed.
+	task.Resume()
+	advance(time.Second)
+	expectRun(time.Unix(8, 0))
+
+	task.Stop()
+	select {
+	case <-task.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for task to stop")
+	}
+}
+
+func TestNode_After(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		clock := maelstrom.NewManualClock(time.Unix(0, 0))
+		n := maelstrom.NewNode()
+		n.Clock = clock
+		runNode(t, n)
+
+		var runs int
+		task := n.After(time.Second, func(ctx context.Context) error {
+			runs++
+			return nil
+		})
+
+		waitUntil(t, func() bool { return clock.Timers() == 1 })
+		clock.Advance(time.Second)
+
+		select {
+		case <-task.Done():
+			if runs != 1 {
+				t.Fatalf("runs=%d, want 1", runs)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for task")
+		}
+	})
+
+	// Ensure pending tasks are stopped when the node shuts down.
+	t.Run("Shutdown", func(t *testing.T) {
+		n := maelstrom.NewNode()
+		n.Clock = maelstrom.NewManualClock(time.Unix(0, 0))
+		stdin := runNode(t, n)
+
+		task := n.After(time.Hour, func(ctx context.Context) error {
+			t.Error("unexpected run")
+			return nil
+		})
+		if err := stdin.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-task.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for task to stop")
+		}
+	})
+
+	// Ensure an error returned by a task shuts down the node.
+	t.Run("Err", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+
+		n := maelstrom.NewNode()
+		n.Stdin = r
+		n.Stdout = io.Discard
+		n.After(time.Millisecond, func(ctx context.Context) error {
+			return errors.New("marker")
+		})
+		if err := n.Run(); err == nil || err.Error() != `marker` {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+// runNode runs n in the background until the returned STDIN writer is
+// closed or the test ends.
+func runNode(tb testing.TB, n *maelstrom.Node) io.WriteCloser {
+	r, w := io.Pipe()
+	n.Stdin = r
+	n.Stdout = io.Discard
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := n.Run(); err != nil {
+			tb.Errorf("run error: %s", err)
+		}
+	}()
+
+	tb.Cleanup(func() {
+		w.Close()
+		<-done
+	})
+	return w
+}
@@ -0,0 +1,142 @@
+package maelstrom
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Validator is implemented by typed request bodies which validate their own
+// fields after decoding. Returning an error replies with a MalformedRequest
+// error, unless the error is already an *RPCError.
+type Validator interface {
+	Validate() error
+}
+
+// TypedHandlerFunc is the function signature for a typed message handler. The
+// returned response is sent back to the sender of msg.
+type TypedHandlerFunc[Req, Resp any] func(msg Message, req Req) (Resp, error)
+
+// HandleTyped registers a typed message handler for a given message type on n.
+// Will panic if registering multiple handlers for the same message type.
+//
+// The message body is decoded into Req. Struct fields tagged with
+// `maelstrom:"required"` must be present in the body and, if Req implements
+// Validator, it is validated. Decoding & validation failures are replied to
+// with a MalformedRequest error. The response is replied to the sender with
+// its "type" set to typ + "_ok", unless the response already sets a type.
+func HandleTyped[Req, Resp any](n *Node, typ string, fn TypedHandlerFunc[Req, Resp]) {
+	n.Handle(typ, NewTypedHandler(n, typ, fn))
+}
+
+// NewTypedHandler returns a HandlerFunc which wraps a typed handler. This can
+// be used to register a typed handler with Node.Handle or to wrap it further.
+// See HandleTyped for details on decoding & replies.
+func NewTypedHandler[Req, Resp any](n *Node, typ string, fn TypedHandlerFunc[Req, Resp]) HandlerFunc {
+	return func(msg Message) error {
+		var req Req
+		if err := DecodeBody(msg, &req); err != nil {
+			return err
+		}
+
+		resp, err := fn(msg, req)
+		if err != nil {
+			return err
+		}
+		return n.replyWithType(msg, typ+"_ok", resp)
+	}
+}
+
+// DecodeBody decodes the body of msg into v, checks required fields & runs
+// validation. Returns an *RPCError with a MalformedRequest code on failure.
+func DecodeBody(msg Message, v any) error {
+	if err := json.Unmarshal(msg.Body, v); err != nil {
+		return NewRPCError(MalformedRequest, fmt.Sprintf("malformed %s body: %s", msg.Type(), err))
+	}
+
+	if fields := requiredFields(reflect.TypeOf(v)); len(fields) > 0 {
+		var present map[string]json.RawMessage
+		if err := json.Unmarshal(msg.Body, &present); err != nil {
+			return NewRPCError(MalformedRequest, fmt.Sprintf("malformed %s body: %s", msg.Type(), err))
+		}
+		for _, name := range fields {
+			if _, ok := present[name]; !ok {
+				return NewRPCError(MalformedRequest, fmt.Sprintf("missing required field %q", name))
+			}
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			if rpcErr, ok := err.(*RPCError); ok {
+				return rpcErr
+			}
+			return NewRPCError(MalformedRequest, err.Error())
+		}
+	}
+	return nil
+}
+
+// replyWithType replies to req with body, setting the "type" field to typ if
+// it is not already set.
+func (n *Node) replyWithType(req Message, typ string, body any) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	if v, err := bodyField(buf, "type"); err != nil {
+		return err
+	} else if v == nil || string(v) == `""` || string(v) == "null" {
+		if buf, err = setBodyRaw(buf, "type", strconv.AppendQuote(nil, typ)); err != nil {
+			return err
+		}
+	}
+	return n.reply(req, buf)
+}
+
+// requiredFieldsCache caches the required JSON field names by type.
+var requiredFieldsCache sync.Map
+
+// requiredFields returns the JSON names of fields tagged as required on the
+// struct type t, or the struct type t points to.
+func requiredFields(t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if v, ok := requiredFieldsCache.Load(t); ok {
+		return v.([]string)
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Include required fields from embedded structs without a JSON name.
+		if f.Anonymous && name == "" {
+			fields = append(fields, requiredFields(f.Type)...)
+			continue
+		}
+
+		if f.Tag.Get("maelstrom") != "required" {
+			continue
+		} else if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+
+	requiredFieldsCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,104 @@
+package maelstrom_test
+
+import (
+	"errors"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+type broadcastRequest struct {
+	maelstrom.MessageBody
+	Message int `json:"message" maelstrom:"required"`
+}
+
+func (r broadcastRequest) Validate() error {
+	if r.Message < 0 {
+		return errors.New("message must not be negative")
+	}
+	return nil
+}
+
+type broadcastResponse struct {
+	Type    string `json:"type,omitempty"`
+	Message int    `json:"message"`
+}
+
+func TestHandleTyped(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		maelstrom.HandleTyped(n, "broadcast", func(msg maelstrom.Message, req broadcastRequest) (broadcastResponse, error) {
+			return broadcastResponse{Message: req.Message}, nil
+		})
+		initNode(t, n, "n1", []string{"n1"}, stdin, stdout)
+
+		// Ensure integers beyond float64 precision are preserved.
+		if _, err := stdin.Write([]byte(`{"src":"c1", "dest":"n1", "body":{"type":"broadcast", "msg_id":2, "message":9007199254740993}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if line, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if got, want := line, `{"src":"n1","dest":"c1","body":{"in_reply_to":2,"message":9007199254740993,"type":"broadcast_ok"}}`+"\n"; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	t.Run("CustomType", func(t *testing.T) {
+		n, stdin, stdout := newNode(t)
+		maelstrom.HandleTyped(n, "broadcast", func(msg maelstrom.Message, req broadcastRequest) (broadcastResponse, error) {
+			return broadcastResponse{Type: "custom", Message: req.Message}, nil
+		})
+		initNode(t, n, "n1", []string{"n1"}, stdin, stdout)
+
+		if _, err := stdin.Write([]byte(`{"src":"c1", "dest":"n1", "body":{"type":"broadcast", "msg_id":2, "message":1}}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if line, err := stdout.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		} else if got, want := line, `{"src":"n1","dest":"c1","body":{"in_reply_to":2,"type":"custom","message":1}}`+"\n"; got != want {
+			t.Fatalf("response=%s, want %s", got, want)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		body string
+		text string
+	}{
+		{"ErrMissingField", `{"type":"broadcast", "msg_id":2}`, `missing required field \"message\"`},
+		{"ErrWrongType", `{"type":"broadcast", "msg_id":2, "message":"foo"}`, `malformed broadcast body: json: cannot unmarshal string into Go struct field broadcastRequest.message of type int`},
+		{"ErrValidate", `{"type":"broadcast", "msg_id":2, "message":-1}`, `message must not be negative`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			n, stdin, stdout := newNode(t)
+			maelstrom.HandleTyped(n, "broadcast", func(msg maelstrom.Message, req broadcastRequest) (broadcastResponse, error) {
+				t.Error("handler should not be called")
+				return broadcastResponse{}, nil
+			})
+			initNode(t, n, "n1", []string{"n1"}, stdin, stdout)
+
+			if _, err := stdin.Write([]byte(`{"src":"c1", "dest":"n1", "body":` + tt.body + `}` + "\n")); err != nil {
+				t.Fatal(err)
+			}
+			if line, err := stdout.ReadString('\n'); err != nil {
+				t.Fatal(err)
+			} else if got, want := line, `{"src":"n1","dest":"c1","body":{"code":12,"in_reply_to":2,"text":"`+tt.text+`","type":"error"}}`+"\n"; got != want {
+				t.Fatalf("response=%s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("ErrDuplicate", func(t *testing.T) {
+		n, _, _ := newNode(t)
+		n.Handle("broadcast", func(msg maelstrom.Message) error { return nil })
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		maelstrom.HandleTyped(n, "broadcast", func(msg maelstrom.Message, req broadcastRequest) (broadcastResponse, error) {
+			return broadcastResponse{}, nil
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package workload
+
+import (
+	"context"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Message types for the broadcast workload.
+const (
+	TypeTopology  = "topology"
+	TypeBroadcast = "broadcast"
+	TypeRead      = "read"
+)
+
+// TopologyRequest represents the body of a "topology" request. Topology maps
+// each node ID to the IDs of its neighbors.
+type TopologyRequest struct {
+	maelstrom.MessageBody
+	Topology map[string][]string `json:"topology" maelstrom:"required"`
+}
+
+// TopologyOK represents the body of a "topology_ok" response.
+type TopologyOK struct {
+	maelstrom.MessageBody
+}
+
+// Topology sends a "topology" request to dest.
+func Topology(ctx context.Context, c Caller, dest string, topology map[string][]string) error {
+	_, err := call[TopologyOK](ctx, c, dest, TopologyRequest{MessageBody: body(TypeTopology), Topology: topology})
+	return err
+}
+
+// BroadcastRequest represents the body of a "broadcast" request.
+type BroadcastRequest struct {
+	maelstrom.MessageBody
+	Message int `json:"message" maelstrom:"required"`
+}
+
+// BroadcastOK represents the body of a "broadcast_ok" response.
+type BroadcastOK struct {
+	maelstrom.MessageBody
+}
+
+// Broadcast sends a "broadcast" request for message to dest.
+func Broadcast(ctx context.Context, c Caller, dest string, message int) error {
+	_, err := call[BroadcastOK](ctx, c, dest, BroadcastRequest{MessageBody: body(TypeBroadcast), Message: message})
+	return err
+}
+
+// BroadcastReadRequest represents the body of a broadcast "read" request.
+type BroadcastReadRequest struct {
+	maelstrom.MessageBody
+}
+
+// BroadcastReadOK represents the body of a broadcast "read_ok" response.
+type BroadcastReadOK struct {
+	maelstrom.MessageBody
+	Messages []int `json:"messages"`
+}
+
+// BroadcastRead sends a "read" request to dest and returns all messages
+// present on the node.
+func BroadcastRead(ctx context.Context, c Caller, dest string) ([]int, error) {
+	resp, err := call[BroadcastReadOK](ctx, c, dest, BroadcastReadRequest{MessageBody: body(TypeRead)})
+	return resp.Messages, err
+}
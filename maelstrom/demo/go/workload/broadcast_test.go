@@ -0,0 +1,91 @@
+package workload_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/simnet"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+func TestBroadcast(t *testing.T) {
+	c := startNode(t, func(n *maelstrom.Node) {
+		var mu sync.Mutex
+		var messages []int
+
+		maelstrom.HandleTyped(n, workload.TypeTopology, func(msg maelstrom.Message, req workload.TopologyRequest) (workload.TopologyOK, error) {
+			if got, want := req.Topology["n1"], []string{"n2"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("topology=%v, want %v", got, want)
+			}
+			return workload.TopologyOK{}, nil
+		})
+		maelstrom.HandleTyped(n, workload.TypeBroadcast, func(msg maelstrom.Message, req workload.BroadcastRequest) (workload.BroadcastOK, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			messages = append(messages, req.Message)
+			return workload.BroadcastOK{}, nil
+		})
+		maelstrom.HandleTyped(n, workload.TypeRead, func(msg maelstrom.Message, req workload.BroadcastReadRequest) (workload.BroadcastReadOK, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return workload.BroadcastReadOK{Messages: messages}, nil
+		})
+	})
+
+	ctx := context.Background()
+	if err := workload.Topology(ctx, c, "n1", map[string][]string{"n1": {"n2"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []int{1, 2, 3} {
+		if err := workload.Broadcast(ctx, c, "n1", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if messages, err := workload.BroadcastRead(ctx, c, "n1"); err != nil {
+		t.Fatal(err)
+	} else if got, want := messages, []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messages=%v, want %v", got, want)
+	}
+}
+
+// Ensure a request missing a required field is rejected by a typed handler.
+func TestBroadcast_ErrMissingMessage(t *testing.T) {
+	c := startNode(t, func(n *maelstrom.Node) {
+		maelstrom.HandleTyped(n, workload.TypeBroadcast, func(msg maelstrom.Message, req workload.BroadcastRequest) (workload.BroadcastOK, error) {
+			return workload.BroadcastOK{}, nil
+		})
+	})
+
+	_, err := c.SyncRPC(context.Background(), "n1", map[string]any{"type": "broadcast"})
+	if got, want := maelstrom.ErrorCode(err), maelstrom.MalformedRequest; got != want {
+		t.Fatalf("code=%d, want %d", got, want)
+	}
+}
+
+// startNode starts a single-node network with handlers registered by fn and
+// returns a client connected to it.
+func startNode(tb testing.TB, fn func(n *maelstrom.Node)) *simnet.Client {
+	tb.Helper()
+
+	n := maelstrom.NewNode()
+	fn(n)
+
+	net := simnet.NewNetwork()
+	net.AddNode("n1", n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := net.Start(ctx); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if err := net.Close(); err != nil {
+			tb.Fatalf("close network: %s", err)
+		}
+	})
+	return net.Client()
+}
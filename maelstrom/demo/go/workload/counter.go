@@ -0,0 +1,79 @@
+package workload
+
+import (
+	"context"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// TypeAdd is the message type for adding to a counter or a set.
+const TypeAdd = "add"
+
+// CounterAddRequest represents the body of a g-counter or pn-counter "add"
+// request. Deltas are never negative for a g-counter.
+type CounterAddRequest struct {
+	maelstrom.MessageBody
+	Delta int `json:"delta" maelstrom:"required"`
+}
+
+// CounterAddOK represents the body of a counter "add_ok" response.
+type CounterAddOK struct {
+	maelstrom.MessageBody
+}
+
+// CounterAdd sends an "add" request for delta to dest.
+func CounterAdd(ctx context.Context, c Caller, dest string, delta int) error {
+	_, err := call[CounterAddOK](ctx, c, dest, CounterAddRequest{MessageBody: body(TypeAdd), Delta: delta})
+	return err
+}
+
+// CounterReadRequest represents the body of a counter "read" request.
+type CounterReadRequest struct {
+	maelstrom.MessageBody
+}
+
+// CounterReadOK represents the body of a counter "read_ok" response.
+type CounterReadOK struct {
+	maelstrom.MessageBody
+	Value int `json:"value"`
+}
+
+// CounterRead sends a "read" request to dest and returns the counter value.
+func CounterRead(ctx context.Context, c Caller, dest string) (int, error) {
+	resp, err := call[CounterReadOK](ctx, c, dest, CounterReadRequest{MessageBody: body(TypeRead)})
+	return resp.Value, err
+}
+
+// GSetAddRequest represents the body of a g-set "add" request.
+type GSetAddRequest struct {
+	maelstrom.MessageBody
+	Element int `json:"element" maelstrom:"required"`
+}
+
+// GSetAddOK represents the body of a g-set "add_ok" response.
+type GSetAddOK struct {
+	maelstrom.MessageBody
+}
+
+// GSetAdd sends an "add" request for element to dest.
+func GSetAdd(ctx context.Context, c Caller, dest string, element int) error {
+	_, err := call[GSetAddOK](ctx, c, dest, GSetAddRequest{MessageBody: body(TypeAdd), Element: element})
+	return err
+}
+
+// GSetReadRequest represents the body of a g-set "read" request.
+type GSetReadRequest struct {
+	maelstrom.MessageBody
+}
+
+// GSetReadOK represents the body of a g-set "read_ok" response.
+type GSetReadOK struct {
+	maelstrom.MessageBody
+	Value []int `json:"value"`
+}
+
+// GSetRead sends a "read" request to dest and returns the set's elements.
+func GSetRead(ctx context.Context, c Caller, dest string) ([]int, error) {
+	resp, err := call[GSetReadOK](ctx, c, dest, GSetReadRequest{MessageBody: body(TypeRead)})
+	return resp.Value, err
+}
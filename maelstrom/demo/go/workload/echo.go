@@ -0,0 +1,49 @@
+package workload
+
+import (
+	"context"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Message types for the echo & unique-ids workloads.
+const (
+	TypeEcho     = "echo"
+	TypeGenerate = "generate"
+)
+
+// EchoRequest represents the body of an "echo" request.
+type EchoRequest struct {
+	maelstrom.MessageBody
+	Echo any `json:"echo" maelstrom:"required"`
+}
+
+// EchoOK represents the body of an "echo_ok" response.
+type EchoOK struct {
+	maelstrom.MessageBody
+	Echo any `json:"echo"`
+}
+
+// Echo sends an "echo" request to dest and returns the echoed payload.
+func Echo(ctx context.Context, c Caller, dest string, echo any) (any, error) {
+	resp, err := call[EchoOK](ctx, c, dest, EchoRequest{MessageBody: body(TypeEcho), Echo: echo})
+	return resp.Echo, err
+}
+
+// GenerateRequest represents the body of a unique-ids "generate" request.
+type GenerateRequest struct {
+	maelstrom.MessageBody
+}
+
+// GenerateOK represents the body of a "generate_ok" response. IDs may be any
+// JSON value.
+type GenerateOK struct {
+	maelstrom.MessageBody
+	ID any `json:"id"`
+}
+
+// Generate sends a "generate" request to dest and returns the generated ID.
+func Generate(ctx context.Context, c Caller, dest string) (any, error) {
+	resp, err := call[GenerateOK](ctx, c, dest, GenerateRequest{MessageBody: body(TypeGenerate)})
+	return resp.ID, err
+}
@@ -0,0 +1,119 @@
+package workload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Message types for the kafka workload.
+const (
+	TypeSend                 = "send"
+	TypePoll                 = "poll"
+	TypeCommitOffsets        = "commit_offsets"
+	TypeListCommittedOffsets = "list_committed_offsets"
+)
+
+// KafkaSendRequest represents the body of a "send" request.
+type KafkaSendRequest struct {
+	maelstrom.MessageBody
+	Key string `json:"key" maelstrom:"required"`
+	Msg int    `json:"msg" maelstrom:"required"`
+}
+
+// KafkaSendOK represents the body of a "send_ok" response.
+type KafkaSendOK struct {
+	maelstrom.MessageBody
+	Offset int `json:"offset"`
+}
+
+// KafkaSend sends msg to be appended to the log for key and returns the
+// offset assigned to it.
+func KafkaSend(ctx context.Context, c Caller, dest, key string, msg int) (int, error) {
+	resp, err := call[KafkaSendOK](ctx, c, dest, KafkaSendRequest{MessageBody: body(TypeSend), Key: key, Msg: msg})
+	return resp.Offset, err
+}
+
+// KafkaPollRequest represents the body of a "poll" request. Offsets maps each
+// key to the first offset to return.
+type KafkaPollRequest struct {
+	maelstrom.MessageBody
+	Offsets map[string]int `json:"offsets" maelstrom:"required"`
+}
+
+// KafkaPollOK represents the body of a "poll_ok" response. Msgs maps each key
+// to a contiguous list of records starting at the requested offset.
+type KafkaPollOK struct {
+	maelstrom.MessageBody
+	Msgs map[string][]KafkaRecord `json:"msgs"`
+}
+
+// KafkaRecord represents a message at an offset in a log. It is encoded as
+// an [offset, msg] pair.
+type KafkaRecord struct {
+	Offset int
+	Msg    int
+}
+
+// MarshalJSON encodes the record as an [offset, msg] pair.
+func (r KafkaRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]int{r.Offset, r.Msg})
+}
+
+// UnmarshalJSON decodes the record from an [offset, msg] pair.
+func (r *KafkaRecord) UnmarshalJSON(data []byte) error {
+	var pair []int
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	} else if len(pair) != 2 {
+		return fmt.Errorf("kafka record must be an [offset, msg] pair: %s", data)
+	}
+	r.Offset, r.Msg = pair[0], pair[1]
+	return nil
+}
+
+// KafkaPoll requests the records for each key starting at the given offsets.
+func KafkaPoll(ctx context.Context, c Caller, dest string, offsets map[string]int) (map[string][]KafkaRecord, error) {
+	resp, err := call[KafkaPollOK](ctx, c, dest, KafkaPollRequest{MessageBody: body(TypePoll), Offsets: offsets})
+	return resp.Msgs, err
+}
+
+// KafkaCommitOffsetsRequest represents the body of a "commit_offsets" request.
+type KafkaCommitOffsetsRequest struct {
+	maelstrom.MessageBody
+	Offsets map[string]int `json:"offsets" maelstrom:"required"`
+}
+
+// KafkaCommitOffsetsOK represents the body of a "commit_offsets_ok" response.
+type KafkaCommitOffsetsOK struct {
+	maelstrom.MessageBody
+}
+
+// KafkaCommitOffsets commits the given offset for each key.
+func KafkaCommitOffsets(ctx context.Context, c Caller, dest string, offsets map[string]int) error {
+	_, err := call[KafkaCommitOffsetsOK](ctx, c, dest, KafkaCommitOffsetsRequest{MessageBody: body(TypeCommitOffsets), Offsets: offsets})
+	return err
+}
+
+// KafkaListCommittedOffsetsRequest represents the body of a
+// "list_committed_offsets" request.
+type KafkaListCommittedOffsetsRequest struct {
+	maelstrom.MessageBody
+	Keys []string `json:"keys" maelstrom:"required"`
+}
+
+// KafkaListCommittedOffsetsOK represents the body of a
+// "list_committed_offsets_ok" response. Keys without a committed offset may
+// be omitted.
+type KafkaListCommittedOffsetsOK struct {
+	maelstrom.MessageBody
+	Offsets map[string]int `json:"offsets"`
+}
+
+// KafkaListCommittedOffsets returns the committed offsets for keys.
+func KafkaListCommittedOffsets(ctx context.Context, c Caller, dest string, keys []string) (map[string]int, error) {
+	resp, err := call[KafkaListCommittedOffsetsOK](ctx, c, dest, KafkaListCommittedOffsetsRequest{MessageBody: body(TypeListCommittedOffsets), Keys: keys})
+	return resp.Offsets, err
+}
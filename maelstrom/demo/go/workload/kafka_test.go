@@ -0,0 +1,101 @@
+package workload_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+func TestKafkaRecord_JSON(t *testing.T) {
+	buf, err := json.Marshal([]workload.KafkaRecord{{Offset: 2, Msg: 9}, {Offset: 3, Msg: 5}})
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), `[[2,9],[3,5]]`; got != want {
+		t.Fatalf("json=%s, want %s", got, want)
+	}
+
+	var records []workload.KafkaRecord
+	if err := json.Unmarshal(buf, &records); err != nil {
+		t.Fatal(err)
+	} else if got, want := records, []workload.KafkaRecord{{Offset: 2, Msg: 9}, {Offset: 3, Msg: 5}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("records=%v, want %v", got, want)
+	}
+
+	if err := json.Unmarshal([]byte(`[[1]]`), &records); err == nil {
+		t.Fatal("expected error for malformed record")
+	}
+}
+
+func TestKafka(t *testing.T) {
+	c := startNode(t, func(n *maelstrom.Node) {
+		var mu sync.Mutex
+		logs := make(map[string][]int)
+		committed := make(map[string]int)
+
+		maelstrom.HandleTyped(n, workload.TypeSend, func(msg maelstrom.Message, req workload.KafkaSendRequest) (workload.KafkaSendOK, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			logs[req.Key] = append(logs[req.Key], req.Msg)
+			return workload.KafkaSendOK{Offset: len(logs[req.Key]) - 1}, nil
+		})
+		maelstrom.HandleTyped(n, workload.TypePoll, func(msg maelstrom.Message, req workload.KafkaPollRequest) (workload.KafkaPollOK, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			msgs := make(map[string][]workload.KafkaRecord)
+			for key, offset := range req.Offsets {
+				for i := offset; i < len(logs[key]); i++ {
+					msgs[key] = append(msgs[key], workload.KafkaRecord{Offset: i, Msg: logs[key][i]})
+				}
+			}
+			return workload.KafkaPollOK{Msgs: msgs}, nil
+		})
+		maelstrom.HandleTyped(n, workload.TypeCommitOffsets, func(msg maelstrom.Message, req workload.KafkaCommitOffsetsRequest) (workload.KafkaCommitOffsetsOK, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for key, offset := range req.Offsets {
+				committed[key] = offset
+			}
+			return workload.KafkaCommitOffsetsOK{}, nil
+		})
+		maelstrom.HandleTyped(n, workload.TypeListCommittedOffsets, func(msg maelstrom.Message, req workload.KafkaListCommittedOffsetsRequest) (workload.KafkaListCommittedOffsetsOK, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			offsets := make(map[string]int)
+			for _, key := range req.Keys {
+				if offset, ok := committed[key]; ok {
+					offsets[key] = offset
+				}
+			}
+			return workload.KafkaListCommittedOffsetsOK{Offsets: offsets}, nil
+		})
+	})
+
+	ctx := context.Background()
+	for i, msg := range []int{10, 11, 12} {
+		if offset, err := workload.KafkaSend(ctx, c, "n1", "k1", msg); err != nil {
+			t.Fatal(err)
+		} else if offset != i {
+			t.Fatalf("offset=%d, want %d", offset, i)
+		}
+	}
+
+	if msgs, err := workload.KafkaPoll(ctx, c, "n1", map[string]int{"k1": 1}); err != nil {
+		t.Fatal(err)
+	} else if got, want := msgs, map[string][]workload.KafkaRecord{"k1": {{Offset: 1, Msg: 11}, {Offset: 2, Msg: 12}}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("msgs=%v, want %v", got, want)
+	}
+
+	if err := workload.KafkaCommitOffsets(ctx, c, "n1", map[string]int{"k1": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if offsets, err := workload.KafkaListCommittedOffsets(ctx, c, "n1", []string{"k1", "k2"}); err != nil {
+		t.Fatal(err)
+	} else if got, want := offsets, map[string]int{"k1": 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("offsets=%v, want %v", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package workload
+
+import (
+	"context"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Message types for the lin-kv workload. Reads use TypeRead.
+const (
+	TypeWrite = "write"
+	TypeCAS   = "cas"
+)
+
+// KVReadRequest represents the body of a lin-kv "read" request.
+type KVReadRequest struct {
+	maelstrom.MessageBody
+	Key int `json:"key" maelstrom:"required"`
+}
+
+// KVReadOK represents the body of a lin-kv "read_ok" response.
+type KVReadOK struct {
+	maelstrom.MessageBody
+	Value int `json:"value"`
+}
+
+// KVRead returns the value of key. Returns an *RPCError with a
+// KeyDoesNotExist code if the key does not exist.
+func KVRead(ctx context.Context, c Caller, dest string, key int) (int, error) {
+	resp, err := call[KVReadOK](ctx, c, dest, KVReadRequest{MessageBody: body(TypeRead), Key: key})
+	return resp.Value, err
+}
+
+// KVWriteRequest represents the body of a lin-kv "write" request.
+type KVWriteRequest struct {
+	maelstrom.MessageBody
+	Key   int `json:"key" maelstrom:"required"`
+	Value int `json:"value" maelstrom:"required"`
+}
+
+// KVWriteOK represents the body of a lin-kv "write_ok" response.
+type KVWriteOK struct {
+	maelstrom.MessageBody
+}
+
+// KVWrite overwrites the value of key.
+func KVWrite(ctx context.Context, c Caller, dest string, key, value int) error {
+	_, err := call[KVWriteOK](ctx, c, dest, KVWriteRequest{MessageBody: body(TypeWrite), Key: key, Value: value})
+	return err
+}
+
+// KVCASRequest represents the body of a lin-kv "cas" request.
+type KVCASRequest struct {
+	maelstrom.MessageBody
+	Key  int `json:"key" maelstrom:"required"`
+	From int `json:"from" maelstrom:"required"`
+	To   int `json:"to" maelstrom:"required"`
+}
+
+// KVCASOK represents the body of a lin-kv "cas_ok" response.
+type KVCASOK struct {
+	maelstrom.MessageBody
+}
+
+// KVCAS sets key to to if its current value is from. Returns an *RPCError
+// with a KeyDoesNotExist or PreconditionFailed code if the swap fails.
+func KVCAS(ctx context.Context, c Caller, dest string, key, from, to int) error {
+	_, err := call[KVCASOK](ctx, c, dest, KVCASRequest{MessageBody: body(TypeCAS), Key: key, From: from, To: to})
+	return err
+}
@@ -0,0 +1,159 @@
+package workload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// TypeTxn is the message type for the txn-rw-register & txn-list-append workloads.
+const TypeTxn = "txn"
+
+// Micro-operation functions used in transactions.
+const (
+	FuncRead   = "r"
+	FuncWrite  = "w"
+	FuncAppend = "append"
+)
+
+// RWOp represents a txn-rw-register micro-operation. It is encoded as an
+// [f, key, value] triple. Value is nil for reads which have not been executed
+// or which observed a nonexistent key.
+type RWOp struct {
+	F     string
+	Key   int
+	Value *int
+}
+
+// RWRead returns a read micro-operation for key.
+func RWRead(key int) RWOp { return RWOp{F: FuncRead, Key: key} }
+
+// RWWrite returns a write micro-operation setting key to value.
+func RWWrite(key, value int) RWOp { return RWOp{F: FuncWrite, Key: key, Value: &value} }
+
+// String returns the operation as an [f key value] triple.
+func (op RWOp) String() string {
+	if op.Value == nil {
+		return fmt.Sprintf("[%s %d nil]", op.F, op.Key)
+	}
+	return fmt.Sprintf("[%s %d %d]", op.F, op.Key, *op.Value)
+}
+
+// MarshalJSON encodes the operation as an [f, key, value] triple.
+func (op RWOp) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{op.F, op.Key, op.Value})
+}
+
+// UnmarshalJSON decodes the operation from an [f, key, value] triple.
+func (op *RWOp) UnmarshalJSON(data []byte) error {
+	var f string
+	var value *int
+	if err := unmarshalTriple(data, &f, &op.Key, &value); err != nil {
+		return err
+	} else if f != FuncRead && f != FuncWrite {
+		return fmt.Errorf("invalid txn-rw-register function %q", f)
+	}
+	op.F, op.Value = f, value
+	return nil
+}
+
+// AppendOp represents a txn-list-append micro-operation. It is encoded as an
+// [f, key, value] triple where the value is Value for appends and List for
+// reads. List is nil for reads which have not been executed or which observed
+// a nonexistent key.
+type AppendOp struct {
+	F     string
+	Key   int
+	Value int
+	List  []int
+}
+
+// AppendRead returns a read micro-operation for key.
+func AppendRead(key int) AppendOp { return AppendOp{F: FuncRead, Key: key} }
+
+// Append returns a micro-operation appending value to the list at key.
+func Append(key, value int) AppendOp { return AppendOp{F: FuncAppend, Key: key, Value: value} }
+
+// MarshalJSON encodes the operation as an [f, key, value] triple.
+func (op AppendOp) MarshalJSON() ([]byte, error) {
+	if op.F == FuncRead {
+		return json.Marshal([]any{op.F, op.Key, op.List})
+	}
+	return json.Marshal([]any{op.F, op.Key, op.Value})
+}
+
+// UnmarshalJSON decodes the operation from an [f, key, value] triple.
+func (op *AppendOp) UnmarshalJSON(data []byte) error {
+	var f string
+	var value json.RawMessage
+	if err := unmarshalTriple(data, &f, &op.Key, &value); err != nil {
+		return err
+	}
+
+	op.F, op.Value, op.List = f, 0, nil
+	switch f {
+	case FuncRead:
+		return json.Unmarshal(value, &op.List)
+	case FuncAppend:
+		return json.Unmarshal(value, &op.Value)
+	default:
+		return fmt.Errorf("invalid txn-list-append function %q", f)
+	}
+}
+
+// unmarshalTriple decodes a 3-element JSON array into f, k & v.
+func unmarshalTriple(data []byte, f, k, v any) error {
+	var a []json.RawMessage
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	} else if len(a) != 3 {
+		return fmt.Errorf("micro-operation must be an [f, key, value] triple: %s", data)
+	}
+
+	if err := json.Unmarshal(a[0], f); err != nil {
+		return err
+	} else if err := json.Unmarshal(a[1], k); err != nil {
+		return err
+	}
+	return json.Unmarshal(a[2], v)
+}
+
+// RWTxnRequest represents the body of a txn-rw-register "txn" request.
+type RWTxnRequest struct {
+	maelstrom.MessageBody
+	Txn []RWOp `json:"txn" maelstrom:"required"`
+}
+
+// RWTxnOK represents the body of a txn-rw-register "txn_ok" response.
+type RWTxnOK struct {
+	maelstrom.MessageBody
+	Txn []RWOp `json:"txn"`
+}
+
+// RWTxn executes a txn-rw-register transaction and returns the completed
+// transaction with read values filled in.
+func RWTxn(ctx context.Context, c Caller, dest string, txn []RWOp) ([]RWOp, error) {
+	resp, err := call[RWTxnOK](ctx, c, dest, RWTxnRequest{MessageBody: body(TypeTxn), Txn: txn})
+	return resp.Txn, err
+}
+
+// AppendTxnRequest represents the body of a txn-list-append "txn" request.
+type AppendTxnRequest struct {
+	maelstrom.MessageBody
+	Txn []AppendOp `json:"txn" maelstrom:"required"`
+}
+
+// AppendTxnOK represents the body of a txn-list-append "txn_ok" response.
+type AppendTxnOK struct {
+	maelstrom.MessageBody
+	Txn []AppendOp `json:"txn"`
+}
+
+// AppendTxn executes a txn-list-append transaction and returns the completed
+// transaction with read values filled in.
+func AppendTxn(ctx context.Context, c Caller, dest string, txn []AppendOp) ([]AppendOp, error) {
+	resp, err := call[AppendTxnOK](ctx, c, dest, AppendTxnRequest{MessageBody: body(TypeTxn), Txn: txn})
+	return resp.Txn, err
+}
@@ -0,0 +1,51 @@
+package workload_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+func TestRWOp_JSON(t *testing.T) {
+	txn := []workload.RWOp{workload.RWRead(1), workload.RWWrite(1, 6)}
+	buf, err := json.Marshal(txn)
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), `[["r",1,null],["w",1,6]]`; got != want {
+		t.Fatalf("json=%s, want %s", got, want)
+	}
+
+	var other []workload.RWOp
+	if err := json.Unmarshal(buf, &other); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, txn) {
+		t.Fatalf("txn=%v, want %v", other, txn)
+	}
+
+	if err := json.Unmarshal([]byte(`[["append",1,2]]`), &other); err == nil {
+		t.Fatal("expected error for invalid function")
+	}
+}
+
+func TestAppendOp_JSON(t *testing.T) {
+	txn := []workload.AppendOp{workload.AppendRead(1), {F: workload.FuncRead, Key: 2, List: []int{8}}, workload.Append(1, 6)}
+	buf, err := json.Marshal(txn)
+	if err != nil {
+		t.Fatal(err)
+	} else if got, want := string(buf), `[["r",1,null],["r",2,[8]],["append",1,6]]`; got != want {
+		t.Fatalf("json=%s, want %s", got, want)
+	}
+
+	var other []workload.AppendOp
+	if err := json.Unmarshal(buf, &other); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(other, txn) {
+		t.Fatalf("txn=%v, want %v", other, txn)
+	}
+
+	if err := json.Unmarshal([]byte(`[["r",1]]`), &other); err == nil {
+		t.Fatal("expected error for malformed operation")
+	}
+}
@@ -0,0 +1,38 @@
+// Package workload defines the request & response bodies for each of the
+// Maelstrom workloads, along with client helpers for issuing them. Servers
+// can decode requests with maelstrom.HandleTyped and test clients can use the
+// helpers against any Caller, such as a maelstrom.Node or simnet.Client.
+package workload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+)
+
+// Caller represents anything which can issue a synchronous RPC request.
+type Caller interface {
+	SyncRPC(ctx context.Context, dest string, body any) (maelstrom.Message, error)
+}
+
+// call sends req to dest and decodes the response body into a Resp.
+func call[Resp any](ctx context.Context, c Caller, dest string, req any) (Resp, error) {
+	var resp Resp
+	msg, err := c.SyncRPC(ctx, dest, req)
+	if err != nil {
+		return resp, err
+	}
+	// The request may have taken effect so the error must not carry a
+	// definite error code.
+	if err := json.Unmarshal(msg.Body, &resp); err != nil {
+		return resp, fmt.Errorf("decode %s response: %w", msg.Type(), err)
+	}
+	return resp, nil
+}
+
+// body returns a MessageBody with the given type.
+func body(typ string) maelstrom.MessageBody {
+	return maelstrom.MessageBody{Type: typ}
+}
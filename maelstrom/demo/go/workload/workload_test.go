@@ -0,0 +1,40 @@
+package workload_test
+
+import (
+	"context"
+	"testing"
+
+	maelstrom "github.com/jepsen-io/maelstrom/demo/go"
+	"github.com/jepsen-io/maelstrom/demo/go/checker"
+	"github.com/jepsen-io/maelstrom/demo/go/workload"
+)
+
+// Ensure an undecodable response is reported as an indeterminate failure.
+func TestCall_MalformedResponse(t *testing.T) {
+	c := callerFunc(func(ctx context.Context, dest string, body any) (maelstrom.Message, error) {
+		return maelstrom.Message{Body: []byte(`{"type":"write_ok","value":"x"}`)}, nil
+	})
+
+	var err error
+	r := checker.NewRecorder()
+	r.Do(0, "read", checker.KVOp{Key: 1}, func() (any, error) {
+		_, err = workload.KVRead(context.Background(), c, "n1", 1)
+		return nil, err
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	} else if got, want := maelstrom.ErrorCode(err), -1; got != want {
+		t.Fatalf("code=%d, want %d", got, want)
+	}
+
+	if h := r.History(); h[len(h)-1].Type != checker.Info {
+		t.Fatalf("type=%v, want %v", h[len(h)-1].Type, checker.Info)
+	}
+}
+
+// callerFunc adapts a function to the workload.Caller interface.
+type callerFunc func(ctx context.Context, dest string, body any) (maelstrom.Message, error)
+
+func (fn callerFunc) SyncRPC(ctx context.Context, dest string, body any) (maelstrom.Message, error) {
+	return fn(ctx, dest, body)
+}